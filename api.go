@@ -0,0 +1,51 @@
+package dynamodb
+
+import "context"
+
+// API is the set of DynamoDB operations implemented by Client.
+// Code depending on API instead of *Client can be tested with dynamodbmock.Mock
+// or decorated by wrapping Client.
+type API interface {
+	BatchExecuteStatement(statements []BatchStatementRequest, bopt *BatchExecuteStatementOption) (*BatchExecuteStatementResult, error)
+	BatchExecuteStatementWithContext(ctx context.Context, statements []BatchStatementRequest, bopt *BatchExecuteStatementOption) (*BatchExecuteStatementResult, error)
+	BatchGetItem(items map[string]KeysAndAttributes, bopt *BatchGetItemOption) (*BatchGetItemResult, error)
+	BatchGetItemWithContext(ctx context.Context, items map[string]KeysAndAttributes, bopt *BatchGetItemOption) (*BatchGetItemResult, error)
+	BatchWriteItem(items map[string][]WriteRequest, bopt *BatchWriteItemOption) (*BatchWriteItemResult, error)
+	BatchWriteItemWithContext(ctx context.Context, items map[string][]WriteRequest, bopt *BatchWriteItemOption) (*BatchWriteItemResult, error)
+	CreateTable(t *Table, topt *TableOption) (*CreateTableResult, error)
+	CreateTableWithContext(ctx context.Context, t *Table, topt *TableOption) (*CreateTableResult, error)
+	DeleteItem(table string, key map[string]AttributeValue, dopt *DeleteItemOption) (*DeleteItemResult, error)
+	DeleteItemWithContext(ctx context.Context, table string, key map[string]AttributeValue, dopt *DeleteItemOption) (*DeleteItemResult, error)
+	DeleteTable(table string) (*DeleteTableResult, error)
+	DeleteTableWithContext(ctx context.Context, table string) (*DeleteTableResult, error)
+	DescribeLimits() (*DescribeLimitsResult, error)
+	DescribeLimitsWithContext(ctx context.Context) (*DescribeLimitsResult, error)
+	DescribeTable(table string) (*DescribeTableResult, error)
+	DescribeTableWithContext(ctx context.Context, table string) (*DescribeTableResult, error)
+	ExecuteStatement(statement string, params []AttributeValue, eopt *ExecuteStatementOption) (*ExecuteStatementResult, error)
+	ExecuteStatementWithContext(ctx context.Context, statement string, params []AttributeValue, eopt *ExecuteStatementOption) (*ExecuteStatementResult, error)
+	ExecuteTransaction(statements []ParameterizedStatement, eopt *ExecuteTransactionOption) (*ExecuteTransactionResult, error)
+	ExecuteTransactionWithContext(ctx context.Context, statements []ParameterizedStatement, eopt *ExecuteTransactionOption) (*ExecuteTransactionResult, error)
+	GetItem(table string, key map[string]AttributeValue, gopt *GetItemOption) (*GetItemResult, error)
+	GetItemWithContext(ctx context.Context, table string, key map[string]AttributeValue, gopt *GetItemOption) (*GetItemResult, error)
+	ListTables(lopt *ListTablesOption) (*ListTablesResult, error)
+	ListTablesWithContext(ctx context.Context, lopt *ListTablesOption) (*ListTablesResult, error)
+	ListTagsOfResource(resourceArn string, lopt *ListTagsOfResourceOption) (*ListTagsOfResourceResult, error)
+	ListTagsOfResourceWithContext(ctx context.Context, resourceArn string, lopt *ListTagsOfResourceOption) (*ListTagsOfResourceResult, error)
+	PutItem(table string, item Item, popt *PutItemOption) (*PutItemResult, error)
+	PutItemWithContext(ctx context.Context, table string, item Item, popt *PutItemOption) (*PutItemResult, error)
+	Query(table string, conditions *KeyConditions, qopt *QueryOption) (*QueryResult, error)
+	QueryWithContext(ctx context.Context, table string, conditions *KeyConditions, qopt *QueryOption) (*QueryResult, error)
+	Scan(table string, sopt *ScanOption) (*ScanResult, error)
+	ScanWithContext(ctx context.Context, table string, sopt *ScanOption) (*ScanResult, error)
+	TagResource(resourceArn string, tags []Tag) error
+	TagResourceWithContext(ctx context.Context, resourceArn string, tags []Tag) error
+	UntagResource(resourceArn string, tagKeys []string) error
+	UntagResourceWithContext(ctx context.Context, resourceArn string, tagKeys []string) error
+	UpdateItem(table string, key map[string]AttributeValue, uopt *UpdateItemOption) (*UpdateItemResult, error)
+	UpdateItemWithContext(ctx context.Context, table string, key map[string]AttributeValue, uopt *UpdateItemOption) (*UpdateItemResult, error)
+	UpdateTable(table string, uopt *UpdateTableOption) (*UpdateTableResult, error)
+	UpdateTableWithContext(ctx context.Context, table string, uopt *UpdateTableOption) (*UpdateTableResult, error)
+}
+
+var _ API = (*Client)(nil)
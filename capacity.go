@@ -0,0 +1,230 @@
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// CapacityUsage reports how much of the provisioned throughput limits
+// described by DescribeLimits is in use by the tables in the account.
+type CapacityUsage struct {
+	Limits DescribeLimitsResult
+	Tables map[string]*TableCapacityUsage
+}
+
+// TableCapacityUsage holds the provisioned throughput of a table and its global secondary indexes.
+type TableCapacityUsage struct {
+	TableName              string
+	Table                  ProvisionedThroughput
+	GlobalSecondaryIndexes map[string]ProvisionedThroughput
+}
+
+// CapacityLimitError is returned when a provisioned throughput exceeds the limits.
+type CapacityLimitError struct {
+	// TableName is empty if the account-level limit is exceeded.
+	TableName string
+
+	ReadCapacityUnits     int64
+	WriteCapacityUnits    int64
+	MaxReadCapacityUnits  int64
+	MaxWriteCapacityUnits int64
+}
+
+func (e *CapacityLimitError) Error() string {
+	scope := "account"
+	if e.TableName != "" {
+		scope = "table '" + e.TableName + "'"
+	}
+	return fmt.Sprintf("dynamodb: provisioned throughput (read: %d, write: %d) exceeds the %s limit (read: %d, write: %d)",
+		e.ReadCapacityUnits, e.WriteCapacityUnits, scope, e.MaxReadCapacityUnits, e.MaxWriteCapacityUnits)
+}
+
+// DescribeCapacityUsage combines DescribeLimits with DescribeTable for every table.
+func (c *Client) DescribeCapacityUsage() (*CapacityUsage, error) {
+	return c.DescribeCapacityUsageWithContext(context.Background())
+}
+
+func (c *Client) DescribeCapacityUsageWithContext(ctx context.Context) (*CapacityUsage, error) {
+	limits, err := c.DescribeLimitsWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	u := &CapacityUsage{
+		Limits: *limits,
+		Tables: map[string]*TableCapacityUsage{},
+	}
+
+	lopt := &ListTablesOption{}
+	for {
+		lret, err := c.ListTablesWithContext(ctx, lopt)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range lret.TableNames {
+			dret, err := c.DescribeTableWithContext(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			u.Tables[name] = newTableCapacityUsage(&dret.Table)
+		}
+		if lret.LastEvaluatedTableName == "" {
+			break
+		}
+		lopt.ExclusiveStartTableName = lret.LastEvaluatedTableName
+	}
+	return u, nil
+}
+
+func newTableCapacityUsage(td *TableDescription) *TableCapacityUsage {
+	tu := &TableCapacityUsage{
+		TableName: td.TableName,
+		Table: ProvisionedThroughput{
+			ReadCapacityUnits:  td.ProvisionedThroughput.ReadCapacityUnits,
+			WriteCapacityUnits: td.ProvisionedThroughput.WriteCapacityUnits,
+		},
+		GlobalSecondaryIndexes: map[string]ProvisionedThroughput{},
+	}
+	for _, gsi := range td.GlobalSecondaryIndexes {
+		tu.GlobalSecondaryIndexes[gsi.IndexName] = ProvisionedThroughput{
+			ReadCapacityUnits:  gsi.ProvisionedThroughput.ReadCapacityUnits,
+			WriteCapacityUnits: gsi.ProvisionedThroughput.WriteCapacityUnits,
+		}
+	}
+	return tu
+}
+
+// ReadCapacityUnits returns the sum of read capacity units of the table and its global secondary indexes.
+func (tu *TableCapacityUsage) ReadCapacityUnits() int64 {
+	rcu := tu.Table.ReadCapacityUnits
+	for _, pt := range tu.GlobalSecondaryIndexes {
+		rcu += pt.ReadCapacityUnits
+	}
+	return rcu
+}
+
+// WriteCapacityUnits returns the sum of write capacity units of the table and its global secondary indexes.
+func (tu *TableCapacityUsage) WriteCapacityUnits() int64 {
+	wcu := tu.Table.WriteCapacityUnits
+	for _, pt := range tu.GlobalSecondaryIndexes {
+		wcu += pt.WriteCapacityUnits
+	}
+	return wcu
+}
+
+// ReadUtilization returns the ratio of read capacity units in use to the per-table limit.
+func (u *CapacityUsage) ReadUtilization(table string) float64 {
+	tu, ok := u.Tables[table]
+	if !ok {
+		return 0
+	}
+	return ratio(tu.ReadCapacityUnits(), u.Limits.TableMaxReadCapacityUnits)
+}
+
+// WriteUtilization returns the ratio of write capacity units in use to the per-table limit.
+func (u *CapacityUsage) WriteUtilization(table string) float64 {
+	tu, ok := u.Tables[table]
+	if !ok {
+		return 0
+	}
+	return ratio(tu.WriteCapacityUnits(), u.Limits.TableMaxWriteCapacityUnits)
+}
+
+// ReadCapacityUnits returns the sum of read capacity units of all tables in the account.
+func (u *CapacityUsage) ReadCapacityUnits() int64 {
+	var rcu int64
+	for _, tu := range u.Tables {
+		rcu += tu.ReadCapacityUnits()
+	}
+	return rcu
+}
+
+// WriteCapacityUnits returns the sum of write capacity units of all tables in the account.
+func (u *CapacityUsage) WriteCapacityUnits() int64 {
+	var wcu int64
+	for _, tu := range u.Tables {
+		wcu += tu.WriteCapacityUnits()
+	}
+	return wcu
+}
+
+// AccountReadUtilization returns the ratio of read capacity units in use to the account limit.
+func (u *CapacityUsage) AccountReadUtilization() float64 {
+	return ratio(u.ReadCapacityUnits(), u.Limits.AccountMaxReadCapacityUnits)
+}
+
+// AccountWriteUtilization returns the ratio of write capacity units in use to the account limit.
+func (u *CapacityUsage) AccountWriteUtilization() float64 {
+	return ratio(u.WriteCapacityUnits(), u.Limits.AccountMaxWriteCapacityUnits)
+}
+
+// TableNames returns the names of tables in sorted order.
+func (u *CapacityUsage) TableNames() []string {
+	names := make([]string, 0, len(u.Tables))
+	for name := range u.Tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckUpdateTable checks whether UpdateTable with uopt stays within the per-table and account limits.
+// It returns *CapacityLimitError if the limits would be exceeded.
+func (u *CapacityUsage) CheckUpdateTable(table string, uopt *UpdateTableOption) error {
+	tu, ok := u.Tables[table]
+	if !ok {
+		return ErrNotFound
+	}
+
+	updated := &TableCapacityUsage{
+		TableName:              tu.TableName,
+		Table:                  tu.Table,
+		GlobalSecondaryIndexes: map[string]ProvisionedThroughput{},
+	}
+	for name, pt := range tu.GlobalSecondaryIndexes {
+		updated.GlobalSecondaryIndexes[name] = pt
+	}
+	if uopt != nil {
+		if uopt.ProvisionedThroughput != (ProvisionedThroughput{}) {
+			updated.Table = uopt.ProvisionedThroughput
+		}
+		for _, gu := range uopt.GlobalSecondaryIndexUpdates {
+			updated.GlobalSecondaryIndexes[gu.Update.IndexName] = gu.Update.ProvisionedThroughput
+		}
+	}
+
+	rcu, wcu := updated.ReadCapacityUnits(), updated.WriteCapacityUnits()
+	if exceeds(rcu, u.Limits.TableMaxReadCapacityUnits) || exceeds(wcu, u.Limits.TableMaxWriteCapacityUnits) {
+		return &CapacityLimitError{
+			TableName:             table,
+			ReadCapacityUnits:     rcu,
+			WriteCapacityUnits:    wcu,
+			MaxReadCapacityUnits:  u.Limits.TableMaxReadCapacityUnits,
+			MaxWriteCapacityUnits: u.Limits.TableMaxWriteCapacityUnits,
+		}
+	}
+
+	accountRCU := u.ReadCapacityUnits() - tu.ReadCapacityUnits() + rcu
+	accountWCU := u.WriteCapacityUnits() - tu.WriteCapacityUnits() + wcu
+	if exceeds(accountRCU, u.Limits.AccountMaxReadCapacityUnits) || exceeds(accountWCU, u.Limits.AccountMaxWriteCapacityUnits) {
+		return &CapacityLimitError{
+			ReadCapacityUnits:     accountRCU,
+			WriteCapacityUnits:    accountWCU,
+			MaxReadCapacityUnits:  u.Limits.AccountMaxReadCapacityUnits,
+			MaxWriteCapacityUnits: u.Limits.AccountMaxWriteCapacityUnits,
+		}
+	}
+	return nil
+}
+
+// exceeds treats zero limit as unlimited.
+func exceeds(units, limit int64) bool {
+	return limit > 0 && units > limit
+}
+
+func ratio(units, limit int64) float64 {
+	if limit == 0 {
+		return 0
+	}
+	return float64(units) / float64(limit)
+}
@@ -0,0 +1,107 @@
+package dynamodb_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nabeken/goamz-dynamodb"
+)
+
+func newCapacityTestServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		req := map[string]string{}
+		json.Unmarshal(body, &req)
+
+		switch strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "DynamoDB_20120810.") {
+		case "DescribeLimits":
+			w.Write([]byte(`{"AccountMaxReadCapacityUnits":80,"AccountMaxWriteCapacityUnits":100,"TableMaxReadCapacityUnits":60,"TableMaxWriteCapacityUnits":60}`))
+		case "ListTables":
+			if req["ExclusiveStartTableName"] == "" {
+				w.Write([]byte(`{"TableNames":["TABLE1"],"LastEvaluatedTableName":"TABLE1"}`))
+			} else {
+				w.Write([]byte(`{"TableNames":["TABLE2"]}`))
+			}
+		case "DescribeTable":
+			switch req["TableName"] {
+			case "TABLE1":
+				w.Write([]byte(`{"Table":{"TableName":"TABLE1","ProvisionedThroughput":{"ReadCapacityUnits":20,"WriteCapacityUnits":10},"GlobalSecondaryIndexes":[{"IndexName":"GSI1","ProvisionedThroughput":{"ReadCapacityUnits":10,"WriteCapacityUnits":10}}]}}`))
+			case "TABLE2":
+				w.Write([]byte(`{"Table":{"TableName":"TABLE2","ProvisionedThroughput":{"ReadCapacityUnits":40,"WriteCapacityUnits":5}}}`))
+			}
+		default:
+			t.Errorf("unexpected request: %s", body)
+		}
+	}))
+}
+
+func TestDescribeCapacityUsage(t *testing.T) {
+	ts := newCapacityTestServer(t)
+	defer ts.Close()
+
+	c := &dynamodb.Client{
+		Auth:              dummyAuth,
+		Region:            dynamodb.Region{DynamoDBEndpoint: ts.URL},
+		DisableCRC32Check: true,
+	}
+	u, err := c.DescribeCapacityUsage()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, []string{"TABLE1", "TABLE2"}, u.TableNames())
+	assert.Equal(t, int64(30), u.Tables["TABLE1"].ReadCapacityUnits())
+	assert.Equal(t, int64(20), u.Tables["TABLE1"].WriteCapacityUnits())
+	assert.Equal(t, int64(70), u.ReadCapacityUnits())
+	assert.Equal(t, int64(25), u.WriteCapacityUnits())
+	assert.Equal(t, 0.5, u.ReadUtilization("TABLE1"))
+	assert.Equal(t, 0.875, u.AccountReadUtilization())
+	assert.Equal(t, 0.25, u.AccountWriteUtilization())
+
+	// within the limits
+	assert.NoError(t, u.CheckUpdateTable("TABLE1", &dynamodb.UpdateTableOption{
+		ProvisionedThroughput: dynamodb.ProvisionedThroughput{
+			ReadCapacityUnits:  30,
+			WriteCapacityUnits: 10,
+		},
+	}))
+
+	// exceeds the per-table limit
+	err = u.CheckUpdateTable("TABLE1", &dynamodb.UpdateTableOption{
+		GlobalSecondaryIndexUpdates: []dynamodb.GlobalSecondaryIndexUpdate{
+			dynamodb.GlobalSecondaryIndexUpdate{
+				Update: dynamodb.GlobalSecondaryIndexAction{
+					IndexName: "GSI1",
+					ProvisionedThroughput: dynamodb.ProvisionedThroughput{
+						ReadCapacityUnits:  50,
+						WriteCapacityUnits: 10,
+					},
+				},
+			},
+		},
+	})
+	if assert.IsType(t, &dynamodb.CapacityLimitError{}, err) {
+		assert.Equal(t, "TABLE1", err.(*dynamodb.CapacityLimitError).TableName)
+		assert.Equal(t, int64(70), err.(*dynamodb.CapacityLimitError).ReadCapacityUnits)
+	}
+
+	// exceeds the account limit
+	err = u.CheckUpdateTable("TABLE2", &dynamodb.UpdateTableOption{
+		ProvisionedThroughput: dynamodb.ProvisionedThroughput{
+			ReadCapacityUnits:  55,
+			WriteCapacityUnits: 5,
+		},
+	})
+	if assert.IsType(t, &dynamodb.CapacityLimitError{}, err) {
+		assert.Equal(t, "", err.(*dynamodb.CapacityLimitError).TableName)
+		assert.Equal(t, int64(85), err.(*dynamodb.CapacityLimitError).ReadCapacityUnits)
+	}
+
+	assert.Equal(t, dynamodb.ErrNotFound, u.CheckUpdateTable("TABLE3", nil))
+}
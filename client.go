@@ -1,42 +1,104 @@
 package dynamodb
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
-	"io/ioutil"
 	"net/http"
+	"sync/atomic"
 	"time"
-
-	"github.com/crowdmob/goamz/aws"
 )
 
 /*
 http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/Welcome.html
 List of actions as of API version 2012-08-10
+	BatchExecuteStatement
 	BatchGetItem
 	BatchWriteItem
 	CreateTable
 	DeleteItem
 	DeleteTable
+	DescribeLimits
 	DescribeTable
+	ExecuteStatement
+	ExecuteTransaction
 	GetItem
 	ListTables
+	ListTagsOfResource
 	PutItem
 	Query
 	Scan
+	TagResource
+	UntagResource
 	UpdateItem
 	UpdateTable
 */
 
 type Client struct {
-	Auth       aws.Auth
-	Region     aws.Region
+	Auth       Auth
+	Region     Region
 	HTTPClient http.Client
+
+	// Credentials provides credentials in place of Auth if set.
+	Credentials CredentialsProvider
+
+	// Retryer retries failed requests. DefaultRetryer is used if nil.
+	Retryer Retryer
+
+	// OperationRetryers overrides Retryer per operation such as "PutItem".
+	OperationRetryers map[string]Retryer
+
+	// RateLimiter paces requests per table if set.
+	RateLimiter RateLimiter
+
+	// ThroughputLimiter paces requests by consumed capacity units if set.
+	ThroughputLimiter *ThroughputLimiter
+
+	// DisableCRC32Check disables verification of successful responses with x-amz-crc32 header.
+	DisableCRC32Check bool
+
+	// Handlers holds middlewares around the stages of Do.
+	Handlers Handlers
+
+	// Logger logs operations as LogConfig tells if set.
+	Logger    Logger
+	LogConfig LogConfig
+
+	// Metrics receives request counts, errors, retries, latency and consumed capacity if set.
+	Metrics Metrics
+
+	// Tracer starts a span per operation and a child span per attempt if set.
+	Tracer Tracer
+
+	// Clock returns the current time to sign requests. time.Now is used if nil.
+	Clock func() time.Time
+
+	// clockOffset holds time.Duration learned by correctClockSkew.
+	clockOffset atomic.Value
+}
+
+func (c *Client) BatchExecuteStatement(statements []BatchStatementRequest, bopt *BatchExecuteStatementOption) (*BatchExecuteStatementResult, error) {
+	return c.BatchExecuteStatementWithContext(context.Background(), statements, bopt)
+}
+
+func (c *Client) BatchExecuteStatementWithContext(ctx context.Context, statements []BatchStatementRequest, bopt *BatchExecuteStatementOption) (*BatchExecuteStatementResult, error) {
+	ret := &BatchExecuteStatementResult{}
+	err := c.DoWithContext(ctx, &RawRequest{"BatchExecuteStatement", struct {
+		Statements []BatchStatementRequest
+		*BatchExecuteStatementOption
+	}{
+		statements,
+		bopt,
+	}}).Scan(ret)
+	return ret, err
 }
 
 func (c *Client) BatchGetItem(items map[string]KeysAndAttributes, bopt *BatchGetItemOption) (*BatchGetItemResult, error) {
+	return c.BatchGetItemWithContext(context.Background(), items, bopt)
+}
+
+func (c *Client) BatchGetItemWithContext(ctx context.Context, items map[string]KeysAndAttributes, bopt *BatchGetItemOption) (*BatchGetItemResult, error) {
 	ret := &BatchGetItemResult{}
-	err := c.Do(&RawRequest{"BatchGetItem", struct {
+	err := c.DoWithContext(ctx, &RawRequest{"BatchGetItem", struct {
 		RequestItems map[string]KeysAndAttributes
 		*BatchGetItemOption
 	}{
@@ -47,8 +109,12 @@ func (c *Client) BatchGetItem(items map[string]KeysAndAttributes, bopt *BatchGet
 }
 
 func (c *Client) BatchWriteItem(items map[string][]WriteRequest, bopt *BatchWriteItemOption) (*BatchWriteItemResult, error) {
+	return c.BatchWriteItemWithContext(context.Background(), items, bopt)
+}
+
+func (c *Client) BatchWriteItemWithContext(ctx context.Context, items map[string][]WriteRequest, bopt *BatchWriteItemOption) (*BatchWriteItemResult, error) {
 	ret := &BatchWriteItemResult{}
-	err := c.Do(&RawRequest{"BatchWriteItem", struct {
+	err := c.DoWithContext(ctx, &RawRequest{"BatchWriteItem", struct {
 		RequestItems map[string][]WriteRequest
 		*BatchWriteItemOption
 	}{
@@ -59,8 +125,12 @@ func (c *Client) BatchWriteItem(items map[string][]WriteRequest, bopt *BatchWrit
 }
 
 func (c *Client) CreateTable(t *Table, topt *TableOption) (*CreateTableResult, error) {
+	return c.CreateTableWithContext(context.Background(), t, topt)
+}
+
+func (c *Client) CreateTableWithContext(ctx context.Context, t *Table, topt *TableOption) (*CreateTableResult, error) {
 	ret := &CreateTableResult{}
-	err := c.Do(&RawRequest{"CreateTable", struct {
+	err := c.DoWithContext(ctx, &RawRequest{"CreateTable", struct {
 		*Table
 		*TableOption
 	}{
@@ -71,8 +141,12 @@ func (c *Client) CreateTable(t *Table, topt *TableOption) (*CreateTableResult, e
 }
 
 func (c *Client) DeleteItem(table string, key map[string]AttributeValue, dopt *DeleteItemOption) (*DeleteItemResult, error) {
+	return c.DeleteItemWithContext(context.Background(), table, key, dopt)
+}
+
+func (c *Client) DeleteItemWithContext(ctx context.Context, table string, key map[string]AttributeValue, dopt *DeleteItemOption) (*DeleteItemResult, error) {
 	ret := &DeleteItemResult{}
-	err := c.Do(&RawRequest{"DeleteItem", struct {
+	err := c.DoWithContext(ctx, &RawRequest{"DeleteItem", struct {
 		TableName string
 		Key       map[string]AttributeValue
 		*DeleteItemOption
@@ -85,8 +159,12 @@ func (c *Client) DeleteItem(table string, key map[string]AttributeValue, dopt *D
 }
 
 func (c *Client) DeleteTable(table string) (*DeleteTableResult, error) {
+	return c.DeleteTableWithContext(context.Background(), table)
+}
+
+func (c *Client) DeleteTableWithContext(ctx context.Context, table string) (*DeleteTableResult, error) {
 	ret := &DeleteTableResult{}
-	err := c.Do(&RawRequest{"DeleteTable", struct {
+	err := c.DoWithContext(ctx, &RawRequest{"DeleteTable", struct {
 		TableName string
 	}{
 		table,
@@ -94,9 +172,23 @@ func (c *Client) DeleteTable(table string) (*DeleteTableResult, error) {
 	return ret, err
 }
 
+func (c *Client) DescribeLimits() (*DescribeLimitsResult, error) {
+	return c.DescribeLimitsWithContext(context.Background())
+}
+
+func (c *Client) DescribeLimitsWithContext(ctx context.Context) (*DescribeLimitsResult, error) {
+	ret := &DescribeLimitsResult{}
+	err := c.DoWithContext(ctx, &RawRequest{"DescribeLimits", struct{}{}}).Scan(ret)
+	return ret, err
+}
+
 func (c *Client) DescribeTable(table string) (*DescribeTableResult, error) {
+	return c.DescribeTableWithContext(context.Background(), table)
+}
+
+func (c *Client) DescribeTableWithContext(ctx context.Context, table string) (*DescribeTableResult, error) {
 	ret := &DescribeTableResult{}
-	err := c.Do(&RawRequest{"DescribeTable", struct {
+	err := c.DoWithContext(ctx, &RawRequest{"DescribeTable", struct {
 		TableName string
 	}{
 		table,
@@ -104,9 +196,48 @@ func (c *Client) DescribeTable(table string) (*DescribeTableResult, error) {
 	return ret, err
 }
 
+// ExecuteStatement executes a PartiQL statement. params may be built with NewParameters.
+func (c *Client) ExecuteStatement(statement string, params []AttributeValue, eopt *ExecuteStatementOption) (*ExecuteStatementResult, error) {
+	return c.ExecuteStatementWithContext(context.Background(), statement, params, eopt)
+}
+
+func (c *Client) ExecuteStatementWithContext(ctx context.Context, statement string, params []AttributeValue, eopt *ExecuteStatementOption) (*ExecuteStatementResult, error) {
+	ret := &ExecuteStatementResult{}
+	err := c.DoWithContext(ctx, &RawRequest{"ExecuteStatement", struct {
+		Statement  string
+		Parameters []AttributeValue `json:",omitempty"`
+		*ExecuteStatementOption
+	}{
+		statement,
+		params,
+		eopt,
+	}}).Scan(ret)
+	return ret, err
+}
+
+func (c *Client) ExecuteTransaction(statements []ParameterizedStatement, eopt *ExecuteTransactionOption) (*ExecuteTransactionResult, error) {
+	return c.ExecuteTransactionWithContext(context.Background(), statements, eopt)
+}
+
+func (c *Client) ExecuteTransactionWithContext(ctx context.Context, statements []ParameterizedStatement, eopt *ExecuteTransactionOption) (*ExecuteTransactionResult, error) {
+	ret := &ExecuteTransactionResult{}
+	err := c.DoWithContext(ctx, &RawRequest{"ExecuteTransaction", struct {
+		TransactStatements []ParameterizedStatement
+		*ExecuteTransactionOption
+	}{
+		statements,
+		eopt,
+	}}).Scan(ret)
+	return ret, err
+}
+
 func (c *Client) GetItem(table string, key map[string]AttributeValue, gopt *GetItemOption) (*GetItemResult, error) {
+	return c.GetItemWithContext(context.Background(), table, key, gopt)
+}
+
+func (c *Client) GetItemWithContext(ctx context.Context, table string, key map[string]AttributeValue, gopt *GetItemOption) (*GetItemResult, error) {
 	ret := &GetItemResult{}
-	err := c.Do(&RawRequest{"GetItem", struct {
+	err := c.DoWithContext(ctx, &RawRequest{"GetItem", struct {
 		TableName string
 		Key       map[string]AttributeValue
 		*GetItemOption
@@ -119,8 +250,12 @@ func (c *Client) GetItem(table string, key map[string]AttributeValue, gopt *GetI
 }
 
 func (c *Client) ListTables(lopt *ListTablesOption) (*ListTablesResult, error) {
+	return c.ListTablesWithContext(context.Background(), lopt)
+}
+
+func (c *Client) ListTablesWithContext(ctx context.Context, lopt *ListTablesOption) (*ListTablesResult, error) {
 	ret := &ListTablesResult{}
-	err := c.Do(&RawRequest{"ListTables", struct {
+	err := c.DoWithContext(ctx, &RawRequest{"ListTables", struct {
 		*ListTablesOption
 	}{
 		lopt,
@@ -128,9 +263,29 @@ func (c *Client) ListTables(lopt *ListTablesOption) (*ListTablesResult, error) {
 	return ret, err
 }
 
+func (c *Client) ListTagsOfResource(resourceArn string, lopt *ListTagsOfResourceOption) (*ListTagsOfResourceResult, error) {
+	return c.ListTagsOfResourceWithContext(context.Background(), resourceArn, lopt)
+}
+
+func (c *Client) ListTagsOfResourceWithContext(ctx context.Context, resourceArn string, lopt *ListTagsOfResourceOption) (*ListTagsOfResourceResult, error) {
+	ret := &ListTagsOfResourceResult{}
+	err := c.DoWithContext(ctx, &RawRequest{"ListTagsOfResource", struct {
+		ResourceArn string
+		*ListTagsOfResourceOption
+	}{
+		resourceArn,
+		lopt,
+	}}).Scan(ret)
+	return ret, err
+}
+
 func (c *Client) PutItem(table string, item Item, popt *PutItemOption) (*PutItemResult, error) {
+	return c.PutItemWithContext(context.Background(), table, item, popt)
+}
+
+func (c *Client) PutItemWithContext(ctx context.Context, table string, item Item, popt *PutItemOption) (*PutItemResult, error) {
 	ret := &PutItemResult{}
-	err := c.Do(&RawRequest{"PutItem", struct {
+	err := c.DoWithContext(ctx, &RawRequest{"PutItem", struct {
 		TableName string
 		Item      Item
 		*PutItemOption
@@ -143,8 +298,12 @@ func (c *Client) PutItem(table string, item Item, popt *PutItemOption) (*PutItem
 }
 
 func (c *Client) Query(table string, conditions *KeyConditions, qopt *QueryOption) (*QueryResult, error) {
+	return c.QueryWithContext(context.Background(), table, conditions, qopt)
+}
+
+func (c *Client) QueryWithContext(ctx context.Context, table string, conditions *KeyConditions, qopt *QueryOption) (*QueryResult, error) {
 	ret := &QueryResult{}
-	err := c.Do(&RawRequest{"Query", struct {
+	err := c.DoWithContext(ctx, &RawRequest{"Query", struct {
 		TableName     string
 		KeyConditions *KeyConditions
 		*QueryOption
@@ -157,8 +316,12 @@ func (c *Client) Query(table string, conditions *KeyConditions, qopt *QueryOptio
 }
 
 func (c *Client) Scan(table string, sopt *ScanOption) (*ScanResult, error) {
+	return c.ScanWithContext(context.Background(), table, sopt)
+}
+
+func (c *Client) ScanWithContext(ctx context.Context, table string, sopt *ScanOption) (*ScanResult, error) {
 	ret := &ScanResult{}
-	err := c.Do(&RawRequest{"Scan", struct {
+	err := c.DoWithContext(ctx, &RawRequest{"Scan", struct {
 		TableName string
 		*ScanOption
 	}{
@@ -168,9 +331,43 @@ func (c *Client) Scan(table string, sopt *ScanOption) (*ScanResult, error) {
 	return ret, err
 }
 
+// TagResource returns no result since DynamoDB responds with an empty body.
+func (c *Client) TagResource(resourceArn string, tags []Tag) error {
+	return c.TagResourceWithContext(context.Background(), resourceArn, tags)
+}
+
+func (c *Client) TagResourceWithContext(ctx context.Context, resourceArn string, tags []Tag) error {
+	return c.DoWithContext(ctx, &RawRequest{"TagResource", struct {
+		ResourceArn string
+		Tags        []Tag
+	}{
+		resourceArn,
+		tags,
+	}}).Error
+}
+
+// UntagResource returns no result since DynamoDB responds with an empty body.
+func (c *Client) UntagResource(resourceArn string, tagKeys []string) error {
+	return c.UntagResourceWithContext(context.Background(), resourceArn, tagKeys)
+}
+
+func (c *Client) UntagResourceWithContext(ctx context.Context, resourceArn string, tagKeys []string) error {
+	return c.DoWithContext(ctx, &RawRequest{"UntagResource", struct {
+		ResourceArn string
+		TagKeys     []string
+	}{
+		resourceArn,
+		tagKeys,
+	}}).Error
+}
+
 func (c *Client) UpdateItem(table string, key map[string]AttributeValue, uopt *UpdateItemOption) (*UpdateItemResult, error) {
+	return c.UpdateItemWithContext(context.Background(), table, key, uopt)
+}
+
+func (c *Client) UpdateItemWithContext(ctx context.Context, table string, key map[string]AttributeValue, uopt *UpdateItemOption) (*UpdateItemResult, error) {
 	ret := &UpdateItemResult{}
-	err := c.Do(&RawRequest{"UpdateItem", struct {
+	err := c.DoWithContext(ctx, &RawRequest{"UpdateItem", struct {
 		TableName string
 		Key       map[string]AttributeValue
 		*UpdateItemOption
@@ -183,8 +380,12 @@ func (c *Client) UpdateItem(table string, key map[string]AttributeValue, uopt *U
 }
 
 func (c *Client) UpdateTable(table string, uopt *UpdateTableOption) (*UpdateTableResult, error) {
+	return c.UpdateTableWithContext(context.Background(), table, uopt)
+}
+
+func (c *Client) UpdateTableWithContext(ctx context.Context, table string, uopt *UpdateTableOption) (*UpdateTableResult, error) {
 	ret := &UpdateTableResult{}
-	err := c.Do(&RawRequest{"UpdateTable", struct {
+	err := c.DoWithContext(ctx, &RawRequest{"UpdateTable", struct {
 		TableName string
 		*UpdateTableOption
 	}{
@@ -195,52 +396,151 @@ func (c *Client) UpdateTable(table string, uopt *UpdateTableOption) (*UpdateTabl
 }
 
 func (c *Client) Do(req *RawRequest) *Response {
+	return c.DoWithContext(context.Background(), req)
+}
+
+// DoWithContext sends req to DynamoDB and retries it as the Retryer tells.
+// Every attempt runs through the stages in Handlers, so the HTTP request is
+// rebuilt and signed again on every attempt.
+// If ctx is canceled or its deadline is exceeded, an in-flight HTTP request and
+// a sleep between retries are aborted and ctx.Err() is returned.
+func (c *Client) DoWithContext(ctx context.Context, req *RawRequest) *Response {
 	j, jerr := json.Marshal(req.Param)
 	if jerr != nil {
 		return &Response{jerr, nil}
 	}
-	hreq, err := http.NewRequest("POST", c.Region.DynamoDBEndpoint+"/", bytes.NewReader(j))
-	if err != nil {
-		return &Response{err, nil}
+
+	if c.ThroughputLimiter != nil && capacityKinds[req.Target] != capacityNone {
+		j, jerr = forceConsumedCapacity(j)
+		if jerr != nil {
+			return &Response{jerr, nil}
+		}
 	}
 
-	hreq.Header.Set("Content-Type", "application/x-amz-json-1.0")
-	hreq.Header.Set("X-Amz-Date", time.Now().UTC().Format(aws.ISO8601BasicFormat))
-	hreq.Header.Set("X-Amz-Target", target(req.Target))
+	var tables []string
+	if c.RateLimiter != nil || c.ThroughputLimiter != nil || c.Logger != nil || c.Metrics != nil || c.Tracer != nil {
+		tables = tableNames(req)
+	}
 
-	token := c.Auth.Token()
-	if token != "" {
-		hreq.Header.Set("X-Amz-Security-Token", token)
+	var span Span
+	if c.Tracer != nil {
+		ctx, span = c.startOperationSpan(ctx, req.Target, tables)
 	}
 
-	signer := aws.NewV4Signer(c.Auth, "dynamodb", c.Region)
-	signer.Sign(hreq)
+	start := time.Now()
+	var r *Request
+	var retry int
+	resp := c.do(ctx, req, j, tables, &r, &retry)
+	latency := time.Since(start)
+	if span != nil {
+		span.SetAttribute(AttrRetries, retry)
+		endSpan(span, r, resp.Error)
+	}
+	if c.Logger != nil {
+		var status int
+		if r != nil {
+			status = statusCode(r)
+		}
+		c.logOperation(req.Target, tables, retry, latency, status, resp.Error)
+	}
+	if c.Metrics != nil {
+		c.reportMetrics(req.Target, tables, retry, latency, resp.json, resp.Error)
+	}
+	return resp
+}
 
-	for attempt := attempts.Start(); attempt.Next(); {
-		resp, err := c.HTTPClient.Do(hreq)
+// do runs attempts until it succeeds or the Retryer gives up.
+// The last attempt and the number of retries are stored into last and retries.
+func (c *Client) do(ctx context.Context, req *RawRequest, j []byte, tables []string, last **Request, retries *int) *Response {
+	var err error
+	var skewCorrected, skewRetry bool
+	retryer := c.retryer(req.Target)
+	for retry := 0; ; retry++ {
+		*retries = retry
+		if retry > 0 && !skewRetry {
+			if serr := sleep(ctx, retryer.RetryDelay(retry, err)); serr != nil {
+				return &Response{serr, nil}
+			}
+		}
+		skewRetry = false
+		if werr := c.wait(ctx, req.Target, tables); werr != nil {
+			return &Response{werr, nil}
+		}
+
+		actx := ctx
+		var span Span
+		if c.Tracer != nil {
+			actx, span = c.startAttemptSpan(ctx, req.Target, retry)
+		}
+		r := &Request{
+			Context:   actx,
+			Operation: req.Target,
+			Param:     req.Param,
+			Body:      j,
+			Attempt:   retry,
+		}
+		*last = r
+		attemptStart := time.Now()
+		err = c.attempt(r)
+		c.logAttempt(r, time.Since(attemptStart), err)
+		if span != nil {
+			endSpan(span, r, err)
+		}
 
-		if err != nil {
-			if shouldRetry(err) {
-				continue
+		body := r.ResponseBody
+		if c.RateLimiter != nil {
+			for _, table := range tables {
+				c.RateLimiter.Update(table, err)
 			}
-			return &Response{err, nil}
 		}
+		if err == nil {
+			if c.ThroughputLimiter != nil {
+				for _, cc := range consumedCapacities(body) {
+					c.ThroughputLimiter.Consume(req.Target, cc)
+				}
+			}
+			return &Response{nil, body}
+		}
+		if ctx.Err() != nil {
+			return &Response{ctx.Err(), nil}
+		}
+		// a clock skew error is retried once immediately with the corrected clock
+		if !skewCorrected && c.correctClockSkew(r, err) {
+			skewCorrected, skewRetry = true, true
+			continue
+		}
+		if retry < retryer.MaxRetries() && retryer.ShouldRetry(err) {
+			continue
+		}
+		return &Response{err, nil}
+	}
+}
 
-		defer resp.Body.Close()
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return &Response{ErrFailedtoReadResponse, nil}
+// wait blocks until the limiters allow a request to tables.
+func (c *Client) wait(ctx context.Context, name string, tables []string) error {
+	for _, table := range tables {
+		if c.RateLimiter != nil {
+			if err := c.RateLimiter.Wait(ctx, table); err != nil {
+				return err
+			}
 		}
-		if resp.StatusCode != 200 {
-			err = NewError(resp, body)
-			if shouldRetry(err) {
-				continue
+		if c.ThroughputLimiter != nil {
+			if err := c.ThroughputLimiter.Wait(ctx, table, name); err != nil {
+				return err
 			}
-			return &Response{err, nil}
 		}
-		return &Response{nil, body}
 	}
-	return &Response{err, nil}
+	return nil
+}
+
+func (c *Client) retryer(target string) Retryer {
+	if r, ok := c.OperationRetryers[target]; ok {
+		return r
+	}
+	if c.Retryer != nil {
+		return c.Retryer
+	}
+	return DefaultRetryer{}
 }
 
 type Response struct {
@@ -1,13 +1,25 @@
 package dynamodb_test
 
 import (
+	"bytes"
+	"context"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"strconv"
+	"sync"
+	"syscall"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/nabeken/goamz-dynamodb"
+	"github.com/nabeken/goamz-dynamodb/dynamodbtest"
 )
 
 func newTestTable(name string) *dynamodb.Table {
@@ -78,8 +90,8 @@ func (s *ClientTestSuite) TestGetItem() {
 	if err != nil {
 		s.T().Fatal(err)
 	}
-	s.Equal("HashKeyVal", ret.Item["TestHashKey"].Data[0])
-	s.Equal("1", ret.Item["TestRangeKey"].Data[0])
+	s.Equal("HashKeyVal", string(ret.Item["TestHashKey"].Data[0]))
+	s.Equal("1", string(ret.Item["TestRangeKey"].Data[0]))
 }
 
 func (s *ClientTestSuite) TestPutUpdateItem() {
@@ -106,9 +118,9 @@ func (s *ClientTestSuite) TestPutUpdateItem() {
 	if err != nil {
 		s.T().Fatal(err)
 	}
-	s.Equal("HashKeyVal", ret.Item["TestHashKey"].Data[0])
-	s.Equal("1", ret.Item["TestRangeKey"].Data[0])
-	s.Equal("2", ret.Item["ATTR"].Data[0])
+	s.Equal("HashKeyVal", string(ret.Item["TestHashKey"].Data[0]))
+	s.Equal("1", string(ret.Item["TestRangeKey"].Data[0]))
+	s.Equal("2", string(ret.Item["ATTR"].Data[0]))
 }
 
 type ClientGSITestSuite struct {
@@ -218,10 +230,10 @@ func (s *ClientGSITestSuite) TestUpdateTable() {
 			s.T().Error()
 			return
 		}
-		s.Equal(10, td.ProvisionedThroughput.ReadCapacityUnits)
-		s.Equal(10, td.ProvisionedThroughput.WriteCapacityUnits)
-		s.Equal(10, td.GlobalSecondaryIndexes[0].ProvisionedThroughput.ReadCapacityUnits)
-		s.Equal(10, td.GlobalSecondaryIndexes[0].ProvisionedThroughput.WriteCapacityUnits)
+		s.Equal(int64(10), td.ProvisionedThroughput.ReadCapacityUnits)
+		s.Equal(int64(10), td.ProvisionedThroughput.WriteCapacityUnits)
+		s.Equal(int64(10), td.GlobalSecondaryIndexes[0].ProvisionedThroughput.ReadCapacityUnits)
+		s.Equal(int64(10), td.GlobalSecondaryIndexes[0].ProvisionedThroughput.WriteCapacityUnits)
 	case <-timeoutChan:
 		close(done)
 		s.T().Errorf("Expect ProvisionedThroughput to be changed, but timed out")
@@ -265,7 +277,7 @@ func (s *ScanTestSuite) TestScan() {
 	if !s.NoError(err) {
 		s.T().FailNow()
 	}
-	s.Equal(s.numOfRecords, ret.Count)
+	s.Equal(int64(s.numOfRecords), ret.Count)
 }
 
 func (s *ScanTestSuite) TestScanFilter() {
@@ -286,7 +298,7 @@ func (s *ScanTestSuite) TestScanFilter() {
 	if !s.NoError(err) {
 		s.T().FailNow()
 	}
-	s.Equal(50, ret.Count)
+	s.Equal(int64(50), ret.Count)
 	for i := range ret.Items {
 		ia, err := strconv.Atoi(string(ret.Items[i]["TestRangeKey"].Data[0]))
 		s.NoError(err)
@@ -344,7 +356,7 @@ func (s *QueryTestSuite) TestQuery() {
 	if !s.NoError(err) {
 		s.T().FailNow()
 	}
-	s.Equal(1, ret.Count)
+	s.Equal(int64(1), ret.Count)
 	s.Equal("0", string(ret.Items[0]["TestRangeKey"].Data[0]))
 }
 
@@ -366,7 +378,7 @@ func (s *QueryTestSuite) TestLimitedQuery() {
 	if !s.NoError(err) {
 		s.T().FailNow()
 	}
-	s.Equal(1, ret.Count)
+	s.Equal(int64(1), ret.Count)
 	s.Equal("0", string(ret.Items[0]["TestRangeKey"].Data[0]))
 }
 
@@ -464,8 +476,8 @@ func (s *QueryOnIndexSuite) TestQueryOnIndex() {
 	if !s.NoError(err) {
 		s.T().FailNow()
 	}
-	s.Equal(1, ret.Count)
-	s.Equal("80", ret.Items[0]["TestRangeKey"].Data[0])
+	s.Equal(int64(1), ret.Count)
+	s.Equal("80", string(ret.Items[0]["TestRangeKey"].Data[0]))
 }
 
 func (s *QueryOnIndexSuite) TestLimitedQueryOnIndex() {
@@ -493,8 +505,8 @@ func (s *QueryOnIndexSuite) TestLimitedQueryOnIndex() {
 	if !s.NoError(err) {
 		s.T().FailNow()
 	}
-	s.Equal(5, ret.Count)
-	s.Equal("0", ret.Items[0]["TestRangeKey"].Data[0])
+	s.Equal(int64(5), ret.Count)
+	s.Equal("0", string(ret.Items[0]["TestRangeKey"].Data[0]))
 }
 
 type BatchTestSuite struct {
@@ -590,7 +602,7 @@ func (s *BatchTestSuite) TestBatchWrite() {
 	if !s.NoError(serr) {
 		s.T().Fatal()
 	}
-	s.Equal(90, sret.Count)
+	s.Equal(int64(90), sret.Count)
 }
 
 func TestBatch(t *testing.T) {
@@ -608,3 +620,273 @@ func TestScan(t *testing.T) {
 func TestClientTestSuite(t *testing.T) {
 	doIntegrationTest(t, new(ClientTestSuite), new(ClientGSITestSuite))
 }
+
+func TestDoWithContext_CancelRequest(t *testing.T) {
+	unblock := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+	defer ts.Close()
+	defer close(unblock)
+
+	c := &dynamodb.Client{
+		Auth:              dummyAuth,
+		Region:            dynamodb.Region{DynamoDBEndpoint: ts.URL},
+		DisableCRC32Check: true,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := c.ListTablesWithContext(ctx, nil)
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestDoWithContext_CancelRetry(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#InternalServerError","message":"internal server error"}`))
+	}))
+	defer ts.Close()
+
+	c := &dynamodb.Client{
+		Auth:              dummyAuth,
+		Region:            dynamodb.Region{DynamoDBEndpoint: ts.URL},
+		DisableCRC32Check: true,
+		Retryer:           dynamodb.DefaultRetryer{MinRetryDelay: time.Second},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.ListTablesWithContext(ctx, nil)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, 1, requests)
+	assert.True(t, time.Since(start) < time.Second, "retry sleep must be aborted")
+}
+
+func TestDo_OperationRetryers(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#InternalServerError","message":"internal server error"}`))
+	}))
+	defer ts.Close()
+
+	c := &dynamodb.Client{
+		Auth:              dummyAuth,
+		Region:            dynamodb.Region{DynamoDBEndpoint: ts.URL},
+		DisableCRC32Check: true,
+		Retryer:           dynamodb.DefaultRetryer{},
+		OperationRetryers: map[string]dynamodb.Retryer{
+			"ListTables": dynamodb.NoOpRetryer{},
+		},
+	}
+	_, err := c.ListTables(nil)
+	if assert.IsType(t, &dynamodb.Error{}, err) {
+		assert.Equal(t, "InternalServerError", err.(*dynamodb.Error).Code)
+	}
+	assert.Equal(t, 1, requests)
+}
+
+// requestRecorder records the request of each attempt and fails an attempt
+// if a response body of previous attempts is still open.
+type requestRecorder struct {
+	transport http.RoundTripper
+
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+	closers  []*closeRecorder
+}
+
+type closeRecorder struct {
+	io.ReadCloser
+	closed bool
+}
+
+func (r *closeRecorder) Close() error {
+	r.closed = true
+	return r.ReadCloser.Close()
+}
+
+func (rr *requestRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	rr.mu.Lock()
+	for i := range rr.closers {
+		if !rr.closers[i].closed {
+			rr.mu.Unlock()
+			return nil, &net.OpError{Op: "dial", Err: syscall.EMFILE}
+		}
+	}
+	body, _ := ioutil.ReadAll(req.Body)
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	rr.requests = append(rr.requests, req)
+	rr.bodies = append(rr.bodies, body)
+	rr.mu.Unlock()
+
+	resp, err := rr.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	rr.mu.Lock()
+	cr := &closeRecorder{ReadCloser: resp.Body}
+	resp.Body = cr
+	rr.closers = append(rr.closers, cr)
+	rr.mu.Unlock()
+	return resp, nil
+}
+
+// newFaultTestClient returns a Client which sends requests to ts through FaultInjector with rules.
+func newFaultTestClient(ts *httptest.Server, rules ...dynamodbtest.Rule) (*dynamodb.Client, *requestRecorder) {
+	rr := &requestRecorder{transport: dynamodbtest.NewFaultInjector(ts.Client().Transport, rules...)}
+	return &dynamodb.Client{
+		Auth:              dummyAuth,
+		Region:            dynamodb.Region{DynamoDBEndpoint: ts.URL},
+		DisableCRC32Check: true,
+		HTTPClient:        http.Client{Transport: rr},
+		Retryer:           dynamodb.DefaultRetryer{MinRetryDelay: time.Millisecond},
+	}, rr
+}
+
+func TestDo_RebuildRequestOnRetry(t *testing.T) {
+	var received [][]byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received = append(received, body)
+		w.Write([]byte(`{"TableNames":["TABLE"]}`))
+	}))
+	defer ts.Close()
+
+	c, rr := newFaultTestClient(ts,
+		dynamodbtest.Rule{Fault: dynamodbtest.FaultConnectionReset, Attempts: []int{1}},
+		dynamodbtest.Rule{Fault: dynamodbtest.FaultInternalServerError, Attempts: []int{2}},
+	)
+	// the clock advances a second on every signature
+	clock := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	c.Clock = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+
+	ret, err := c.ListTables(&dynamodb.ListTablesOption{Limit: 10})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"TABLE"}, ret.TableNames)
+
+	if assert.Len(t, rr.requests, 3) {
+		dates := map[string]bool{}
+		signatures := map[string]bool{}
+		for i := range rr.requests {
+			assert.Equal(t, `{"Limit":10}`, string(rr.bodies[i]), "attempt %d must send the whole body", i)
+			dates[rr.requests[i].Header.Get("X-Amz-Date")] = true
+			signatures[rr.requests[i].Header.Get("Authorization")] = true
+		}
+		assert.Equal(t, map[string]bool{
+			"20150830T123601Z": true,
+			"20150830T123602Z": true,
+			"20150830T123603Z": true,
+		}, dates, "request must be signed again on every attempt")
+		assert.Len(t, signatures, 3)
+		assert.True(t, rr.requests[0] != rr.requests[1] && rr.requests[1] != rr.requests[2], "request must be rebuilt on every attempt")
+	}
+	assert.Equal(t, [][]byte{[]byte(`{"Limit":10}`)}, received)
+}
+
+func TestDo_CloseResponseBodyOnRetry(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"TableNames":["TABLE"]}`))
+	}))
+	defer ts.Close()
+
+	// requestRecorder fails an attempt if a response body of previous attempts is still open
+	c, rr := newFaultTestClient(ts, dynamodbtest.Rule{Fault: dynamodbtest.FaultInternalServerError, Attempts: []int{1, 2, 3}})
+
+	_, err := c.ListTables(nil)
+	assert.NoError(t, err)
+	assert.Len(t, rr.requests, 4)
+	for i := range rr.closers {
+		assert.True(t, rr.closers[i].closed, "response body of attempt %d must be closed", i)
+	}
+}
+
+func TestDo_GiveUpRetry(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"TableNames":["TABLE"]}`))
+	}))
+	defer ts.Close()
+
+	c, rr := newFaultTestClient(ts, dynamodbtest.Rule{Fault: dynamodbtest.FaultInternalServerError, Attempts: []int{1, 2, 3}})
+	c.Retryer = dynamodb.DefaultRetryer{NumMaxRetries: 2, MinRetryDelay: time.Millisecond}
+
+	_, err := c.ListTables(nil)
+	if assert.IsType(t, &dynamodb.Error{}, err) {
+		assert.Equal(t, 500, err.(*dynamodb.Error).StatusCode)
+	}
+	assert.Len(t, rr.requests, 3)
+}
+
+func TestDo_CRC32(t *testing.T) {
+	body := []byte(`{"TableNames":["TABLE"]}`)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Amz-Crc32", strconv.FormatUint(uint64(crc32.ChecksumIEEE(body)), 10))
+		w.Write(body)
+	}))
+	defer ts.Close()
+
+	c, rr := newFaultTestClient(ts, dynamodbtest.Rule{Fault: dynamodbtest.FaultCorruptedBody, Attempts: []int{1}})
+	c.DisableCRC32Check = false
+
+	// a mismatch is retried
+	ret, err := c.ListTables(nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"TABLE"}, ret.TableNames)
+	}
+	assert.Len(t, rr.requests, 2)
+}
+
+func TestDo_CRC32Missing(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"Attributes":{"Count":{"N":"1"}}}`))
+	}))
+	defer ts.Close()
+
+	c, _ := newFaultTestClient(ts)
+	c.DisableCRC32Check = false
+
+	// a successful response without the header passes and a write is sent only once
+	_, err := c.UpdateItem("TABLE", dynamodb.Item{"Id": dynamodb.NewString("id")}, &dynamodb.UpdateItemOption{
+		AttributeUpdates: map[string]dynamodb.AttributeUpdate{
+			"Count": {Action: dynamodb.ActionAdd, Value: dynamodb.NewNumber(1)},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, requests)
+}
+
+func TestDo_CRC32MissingInError(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`<html><body>503 Service Unavailable</body></html>`))
+	}))
+	defer ts.Close()
+
+	c, _ := newFaultTestClient(ts)
+	c.DisableCRC32Check = false
+	c.Retryer = dynamodb.DefaultRetryer{NumMaxRetries: 2, MinRetryDelay: time.Millisecond}
+
+	// an error from a proxy has no x-amz-crc32 header
+	_, err := c.ListTables(nil)
+	if assert.IsType(t, &dynamodb.Error{}, err) {
+		assert.Equal(t, http.StatusServiceUnavailable, err.(*dynamodb.Error).StatusCode)
+	}
+	assert.Equal(t, 3, requests, "503 must be retried")
+}
@@ -0,0 +1,68 @@
+package dynamodb
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Error codes returned when the clock of the client is skewed.
+const (
+	CodeRequestTimeTooSkewed = "RequestTimeTooSkewed"
+	CodeRequestExpired       = "RequestExpired"
+	CodeRequestInTheFuture   = "RequestInTheFuture"
+)
+
+// IsClockSkew reports whether err is caused by the skewed clock of the client.
+func IsClockSkew(err error) bool {
+	switch ErrorCode(err) {
+	case CodeRequestTimeTooSkewed, CodeRequestExpired, CodeRequestInTheFuture:
+		return true
+	case CodeInvalidSignature:
+		// "Signature expired: 20150830T123600Z is now earlier than ..." or "Signature not yet current: ..."
+		msg := err.Error()
+		return strings.Contains(msg, "Signature expired") || strings.Contains(msg, "Signature not yet current")
+	}
+	return false
+}
+
+// ClockOffset returns the offset of the server clock to the local clock.
+// It is applied to X-Amz-Date and learned from the Date header of a response to a clock skew error.
+func (c *Client) ClockOffset() time.Duration {
+	if d, ok := c.clockOffset.Load().(time.Duration); ok {
+		return d
+	}
+	return 0
+}
+
+// clock returns the current time on the local clock.
+func (c *Client) clock() time.Time {
+	if c.Clock != nil {
+		return c.Clock()
+	}
+	return time.Now()
+}
+
+// now returns the current time on the server clock.
+func (c *Client) now() time.Time {
+	return c.clock().Add(c.ClockOffset())
+}
+
+// correctClockSkew updates the clock offset from the response to the clock skew error.
+// It reports whether the offset is updated so that the request should be signed again.
+func (c *Client) correctClockSkew(r *Request, err error) bool {
+	if !IsClockSkew(err) || r.HTTPResponse == nil {
+		return false
+	}
+	date, perr := http.ParseTime(r.HTTPResponse.Header.Get("Date"))
+	if perr != nil {
+		return false
+	}
+	offset := date.Sub(c.clock())
+	if d := offset - c.ClockOffset(); d > -time.Second && d < time.Second {
+		// the offset is already applied within the precision of Date
+		return false
+	}
+	c.clockOffset.Store(offset)
+	return true
+}
@@ -0,0 +1,115 @@
+package dynamodb_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nabeken/goamz-dynamodb"
+)
+
+func TestClient_ClockSkew(t *testing.T) {
+	serverNow := time.Now().Add(-time.Hour).UTC()
+	var dates []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dates = append(dates, r.Header.Get("X-Amz-Date"))
+		w.Header().Set("Date", serverNow.Format(http.TimeFormat))
+
+		d, _ := time.Parse(dynamodb.ISO8601BasicFormat, r.Header.Get("X-Amz-Date"))
+		if d.Sub(serverNow) > 5*time.Minute {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"com.amazon.coral.service#InvalidSignatureException","message":"Signature not yet current: ` + d.Format(dynamodb.ISO8601BasicFormat) + ` is still later than ` + serverNow.Add(5*time.Minute).Format(dynamodb.ISO8601BasicFormat) + `"}`))
+			return
+		}
+		w.Write([]byte(`{"TableNames":[]}`))
+	}))
+	defer ts.Close()
+
+	c := &dynamodb.Client{
+		Auth:              dummyAuth,
+		Region:            dynamodb.Region{DynamoDBEndpoint: ts.URL},
+		DisableCRC32Check: true,
+		Retryer:           dynamodb.NoOpRetryer{},
+	}
+
+	_, err := c.ListTables(nil)
+	assert.NoError(t, err)
+	assert.Len(t, dates, 2)
+	assert.InDelta(t, -time.Hour, c.ClockOffset(), float64(2*time.Second))
+
+	// the offset is applied to the later requests
+	_, err = c.ListTables(nil)
+	assert.NoError(t, err)
+	assert.Len(t, dates, 3)
+}
+
+func TestClient_ClockSkewWithClock(t *testing.T) {
+	clock := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	serverNow := clock.Add(time.Hour)
+	var dates []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dates = append(dates, r.Header.Get("X-Amz-Date"))
+		w.Header().Set("Date", serverNow.Format(http.TimeFormat))
+		if len(dates) == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"com.amazon.coral.service#RequestTimeTooSkewed","message":"skewed"}`))
+			return
+		}
+		w.Write([]byte(`{"TableNames":[]}`))
+	}))
+	defer ts.Close()
+
+	c := &dynamodb.Client{
+		Auth:              dummyAuth,
+		Region:            dynamodb.Region{DynamoDBEndpoint: ts.URL},
+		DisableCRC32Check: true,
+		Retryer:           dynamodb.NoOpRetryer{},
+		Clock:             func() time.Time { return clock },
+	}
+
+	// the offset is measured against Clock, not the wall clock
+	_, err := c.ListTables(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Hour, c.ClockOffset())
+	assert.Equal(t, []string{"20150830T123600Z", "20150830T133600Z"}, dates)
+}
+
+func TestClient_ClockSkewRetriedOnce(t *testing.T) {
+	var n int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		// the server clock keeps moving away
+		w.Header().Set("Date", time.Now().Add(time.Duration(n)*time.Hour).UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type":"com.amazon.coral.service#RequestTimeTooSkewed","message":"skewed"}`))
+	}))
+	defer ts.Close()
+
+	c := &dynamodb.Client{
+		Auth:              dummyAuth,
+		Region:            dynamodb.Region{DynamoDBEndpoint: ts.URL},
+		DisableCRC32Check: true,
+		Retryer:           dynamodb.NoOpRetryer{},
+	}
+	_, err := c.ListTables(nil)
+	assert.True(t, dynamodb.IsClockSkew(err))
+	assert.Equal(t, 2, n)
+}
+
+func TestIsClockSkew(t *testing.T) {
+	for _, tc := range []struct {
+		err    error
+		expect bool
+	}{
+		{&dynamodb.Error{Code: "RequestTimeTooSkewed"}, true},
+		{&dynamodb.Error{Code: "InvalidSignatureException", Message: "Signature expired: 20150830T123600Z is now earlier than 20150830T124600Z"}, true},
+		{&dynamodb.Error{Code: "InvalidSignatureException", Message: "The request signature we calculated does not match the signature you provided."}, false},
+		{dynamodb.ErrThrottling, false},
+		{nil, false},
+	} {
+		assert.Equal(t, tc.expect, dynamodb.IsClockSkew(tc.err), "%v", tc.err)
+	}
+}
@@ -0,0 +1,379 @@
+package dynamodb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidCondition is returned by Evaluate if a condition is rejected by DynamoDB
+// such as a wrong number of arguments for the operator.
+var ErrInvalidCondition = errors.New("dynamodb: invalid condition")
+
+// Evaluate reports whether item satisfies filter as DynamoDB evaluates Expected,
+// QueryFilter and ScanFilter. The conditions are combined by op, and AND if op is empty.
+//
+// Numbers are compared by their values, strings by their UTF-8 bytes and binaries
+// by their decoded bytes. Values of different types are never equal. Maps and lists are
+// equal if their elements are equal. CONTAINS tests a substring or a member of a set or a list. NE and NOT_CONTAINS are satisfied if the attribute
+// does not exist. Every condition is validated even if the result is already decided.
+func Evaluate(item Item, filter map[string]Condition, op ConditionalOperator) (bool, error) {
+	switch op {
+	case "", CondOpAnd, CondOpOr:
+	default:
+		return false, fmt.Errorf("%w: unsupported ConditionalOperator %s", ErrInvalidCondition, op)
+	}
+	if len(filter) == 0 {
+		return true, nil
+	}
+
+	ret := op != CondOpOr
+	for name, cond := range filter {
+		var av *AttributeValue
+		if v, ok := item[name]; ok {
+			av = &v
+		}
+		ok, err := match(av, cond)
+		if err != nil {
+			return false, err
+		}
+		if op == CondOpOr {
+			ret = ret || ok
+		} else {
+			ret = ret && ok
+		}
+	}
+	return ret, nil
+}
+
+// match reports whether av satisfies cond. av is nil if the attribute does not exist.
+func match(av *AttributeValue, cond Condition) (bool, error) {
+	args := cond.AttributeValueList
+	if err := validateCondition(cond); err != nil {
+		return false, err
+	}
+
+	switch cond.ComparisonOperator {
+	case CmpOpNull:
+		return av == nil, nil
+	case CmpOpNotNull:
+		return av != nil, nil
+	case CmpOpNE:
+		return av == nil || !equal(*av, args[0]), nil
+	case CmpOpNotContains:
+		return av == nil || !contains(*av, args[0]), nil
+	}
+
+	if av == nil {
+		return false, nil
+	}
+	switch cond.ComparisonOperator {
+	case CmpOpEQ:
+		return equal(*av, args[0]), nil
+	case CmpOpLT, CmpOpLE, CmpOpGT, CmpOpGE:
+		c, ok := Compare(*av, args[0])
+		if !ok {
+			return false, nil
+		}
+		switch cond.ComparisonOperator {
+		case CmpOpLT:
+			return c < 0, nil
+		case CmpOpLE:
+			return c <= 0, nil
+		case CmpOpGT:
+			return c > 0, nil
+		}
+		return c >= 0, nil
+	case CmpOpContains:
+		return contains(*av, args[0]), nil
+	case CmpOpBeginsWith:
+		return beginsWith(*av, args[0]), nil
+	case CmpOpIn:
+		for _, arg := range args {
+			if equal(*av, arg) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case CmpOpBetween:
+		lower, ok := Compare(*av, args[0])
+		if !ok {
+			return false, nil
+		}
+		upper, ok := Compare(*av, args[1])
+		return ok && lower >= 0 && upper <= 0, nil
+	}
+	return false, nil
+}
+
+// validateCondition checks the number and the types of AttributeValueList for the operator.
+func validateCondition(cond Condition) error {
+	args := cond.AttributeValueList
+	nargs := func(n int) error {
+		if len(args) != n {
+			return fmt.Errorf("%w: invalid number of arguments for %s", ErrInvalidCondition, cond.ComparisonOperator)
+		}
+		return nil
+	}
+	scalar := func() error {
+		for _, arg := range args {
+			switch arg.Type {
+			case TypeString, TypeNumber, TypeBinary:
+			default:
+				return fmt.Errorf("%w: %s is not valid for %s", ErrInvalidCondition, cond.ComparisonOperator, arg.Type)
+			}
+		}
+		return nil
+	}
+	for _, arg := range args {
+		if err := validateArgument(arg); err != nil {
+			return err
+		}
+	}
+
+	switch cond.ComparisonOperator {
+	case CmpOpNull, CmpOpNotNull:
+		return nargs(0)
+	case CmpOpEQ, CmpOpNE:
+		return nargs(1)
+	case CmpOpLT, CmpOpLE, CmpOpGT, CmpOpGE,
+		CmpOpContains, CmpOpNotContains:
+		if err := nargs(1); err != nil {
+			return err
+		}
+		return scalar()
+	case CmpOpBeginsWith:
+		if err := nargs(1); err != nil {
+			return err
+		}
+		if t := args[0].Type; t != TypeString && t != TypeBinary {
+			return fmt.Errorf("%w: %s is not valid for %s", ErrInvalidCondition, cond.ComparisonOperator, t)
+		}
+		return nil
+	case CmpOpIn:
+		if len(args) == 0 {
+			return nargs(1)
+		}
+		return scalar()
+	case CmpOpBetween:
+		if err := nargs(2); err != nil {
+			return err
+		}
+		if err := scalar(); err != nil {
+			return err
+		}
+		c, ok := Compare(args[0], args[1])
+		if !ok {
+			return fmt.Errorf("%w: arguments for BETWEEN must be of the same type", ErrInvalidCondition)
+		}
+		if c > 0 {
+			return fmt.Errorf("%w: the lower bound of BETWEEN is greater than the upper bound", ErrInvalidCondition)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: unsupported ComparisonOperator %s", ErrInvalidCondition, cond.ComparisonOperator)
+}
+
+// validateArgument checks that an argument has a value which DynamoDB accepts for the type.
+func validateArgument(av AttributeValue) error {
+	switch av.Type {
+	case TypeMap:
+		for _, v := range av.Map {
+			if err := validateArgument(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	case TypeList:
+		for _, v := range av.List {
+			if err := validateArgument(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(av.Data) == 0 {
+		return fmt.Errorf("%w: empty %s argument", ErrInvalidCondition, av.Type)
+	}
+	for _, d := range av.Data {
+		switch av.Type {
+		case TypeNumber, TypeNumberSet:
+			if _, ok := ParseNumber(d); !ok {
+				return fmt.Errorf("%w: %q is not a number", ErrInvalidCondition, d)
+			}
+		case TypeBinary, TypeBinarySet:
+			if _, err := base64.StdEncoding.DecodeString(string(d)); err != nil {
+				return fmt.Errorf("%w: %q is not base64-encoded", ErrInvalidCondition, d)
+			}
+		}
+	}
+	return nil
+}
+
+// equal reports whether a and b have the same type and value. Sets are equal if they have the same elements.
+func equal(a, b AttributeValue) bool {
+	if a.Type != b.Type {
+		return false
+	}
+	switch a.Type {
+	case TypeMap:
+		if len(a.Map) != len(b.Map) {
+			return false
+		}
+		for name, v := range a.Map {
+			if w, ok := b.Map[name]; !ok || !equal(v, w) {
+				return false
+			}
+		}
+		return true
+	case TypeList:
+		if len(a.List) != len(b.List) {
+			return false
+		}
+		for i := range a.List {
+			if !equal(a.List[i], b.List[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	if a.Type.IsSet() {
+		if len(a.Data) != len(b.Data) {
+			return false
+		}
+		for _, d := range b.Data {
+			if !setContains(a, d) {
+				return false
+			}
+		}
+		return true
+	}
+	if len(a.Data) == 0 || len(b.Data) == 0 {
+		return len(a.Data) == len(b.Data)
+	}
+	c, ok := Compare(a, b)
+	if ok {
+		return c == 0
+	}
+	return a.Data[0] == b.Data[0]
+}
+
+// Compare compares scalar values of the same type, S, N or B, in the order of DynamoDB.
+// Numbers are compared by value and binaries as unsigned bytes.
+// It reports false if the values are not comparable.
+func Compare(a, b AttributeValue) (int, bool) {
+	if a.Type != b.Type || a.Type.IsSet() || len(a.Data) == 0 || len(b.Data) == 0 {
+		return 0, false
+	}
+	return compareData(a.Type, a.Data[0], b.Data[0])
+}
+
+func compareData(t AttributeType, a, b AttributeData) (int, bool) {
+	switch t {
+	case TypeString, TypeStringSet:
+		return strings.Compare(string(a), string(b)), true
+	case TypeNumber, TypeNumberSet:
+		ra, ok := ParseNumber(a)
+		if !ok {
+			return 0, false
+		}
+		rb, ok := ParseNumber(b)
+		if !ok {
+			return 0, false
+		}
+		return ra.Cmp(rb), true
+	case TypeBinary, TypeBinarySet:
+		return bytes.Compare(decodeBinary(a), decodeBinary(b)), true
+	}
+	return 0, false
+}
+
+func contains(av, v AttributeValue) bool {
+	if len(v.Data) == 0 {
+		return false
+	}
+	switch {
+	case av.Type == TypeList:
+		for _, e := range av.List {
+			if equal(e, v) {
+				return true
+			}
+		}
+		return false
+	case av.Type == TypeString && v.Type == TypeString:
+		return strings.Contains(string(av.Data[0]), string(v.Data[0]))
+	case av.Type == TypeBinary && v.Type == TypeBinary:
+		return bytes.Contains(decodeBinary(av.Data[0]), decodeBinary(v.Data[0]))
+	case av.Type == TypeStringSet && v.Type == TypeString,
+		av.Type == TypeNumberSet && v.Type == TypeNumber,
+		av.Type == TypeBinarySet && v.Type == TypeBinary:
+		return setContains(av, v.Data[0])
+	}
+	return false
+}
+
+func beginsWith(av, v AttributeValue) bool {
+	if av.Type != v.Type || len(av.Data) == 0 || len(v.Data) == 0 {
+		return false
+	}
+	switch av.Type {
+	case TypeString:
+		return strings.HasPrefix(string(av.Data[0]), string(v.Data[0]))
+	case TypeBinary:
+		return bytes.HasPrefix(decodeBinary(av.Data[0]), decodeBinary(v.Data[0]))
+	}
+	return false
+}
+
+func setContains(set AttributeValue, d AttributeData) bool {
+	for _, e := range set.Data {
+		if c, ok := compareData(set.Type, e, d); ok && c == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// numberPattern is the decimal notation of numbers which DynamoDB accepts.
+var numberPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(?:\.([0-9]+))?(?:[eE]([+-]?[0-9]+))?$`)
+
+// ParseNumber parses a number attribute without losing precision.
+// It rejects numbers which DynamoDB rejects: notations other than decimal,
+// more than 38 significant digits and magnitudes out of 1E-130 to 1E+126.
+func ParseNumber(d AttributeData) (*big.Rat, bool) {
+	m := numberPattern.FindStringSubmatch(string(d))
+	if m == nil {
+		return nil, false
+	}
+	if digits := strings.TrimLeft(m[1]+m[2], "0"); digits != "" {
+		if len(strings.TrimRight(digits, "0")) > 38 {
+			return nil, false
+		}
+		var exp int
+		if m[3] != "" {
+			e, err := strconv.Atoi(m[3])
+			if err != nil {
+				return nil, false
+			}
+			exp = e
+		}
+		// the exponent of the most significant digit
+		if e := len(digits) - 1 - len(m[2]) + exp; e < -130 || e > 125 {
+			return nil, false
+		}
+	}
+	return new(big.Rat).SetString(string(d))
+}
+
+func decodeBinary(d AttributeData) []byte {
+	b, err := base64.StdEncoding.DecodeString(string(d))
+	if err != nil {
+		return []byte(d)
+	}
+	return b
+}
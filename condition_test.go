@@ -0,0 +1,205 @@
+package dynamodb_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nabeken/goamz-dynamodb"
+)
+
+func cond(op dynamodb.ComparisonOperator, args ...interface{}) dynamodb.Condition {
+	c := dynamodb.Condition{ComparisonOperator: op}
+	for _, arg := range args {
+		av, err := dynamodb.NewAttributeValue(arg)
+		if err != nil {
+			panic(err)
+		}
+		c.AttributeValueList = append(c.AttributeValueList, av)
+	}
+	return c
+}
+
+func TestEvaluate(t *testing.T) {
+	item := dynamodb.Item{
+		"Name":   dynamodb.NewString("goamz"),
+		"Count":  dynamodb.AttributeValue{Type: dynamodb.TypeNumber, Data: []dynamodb.AttributeData{"10.0"}},
+		"Data":   mustAttributeValue([]byte{0x01, 0x02, 0xff}),
+		"Tags":   dynamodb.NewStringSet("a", "b"),
+		"Scores": dynamodb.NewNumberSet(1, 2, 3),
+		"Active": dynamodb.NewBool(true),
+		"Empty":  dynamodb.NewNull(),
+		"Doc":    dynamodb.NewMap(map[string]dynamodb.AttributeValue{"Count": dynamodb.NewNumber(1)}),
+		"List":   dynamodb.NewList(dynamodb.NewString("a"), dynamodb.NewNumber(1)),
+	}
+
+	for _, tc := range []struct {
+		name   string
+		attr   string
+		cond   dynamodb.Condition
+		expect bool
+	}{
+		{"EQ number by value", "Count", cond(dynamodb.CmpOpEQ, 10), true},
+		{"EQ different type", "Count", cond(dynamodb.CmpOpEQ, "10"), false},
+		{"EQ set in any order", "Tags", cond(dynamodb.CmpOpEQ, dynamodb.NewStringSet("b", "a")), true},
+		{"EQ bool", "Active", cond(dynamodb.CmpOpEQ, true), true},
+		{"NE", "Name", cond(dynamodb.CmpOpNE, "goamz"), false},
+		{"NE missing", "Missing", cond(dynamodb.CmpOpNE, "goamz"), true},
+		{"LT number is not lexical", "Count", cond(dynamodb.CmpOpLT, 9), false},
+		{"LE number", "Count", cond(dynamodb.CmpOpLE, 10), true},
+		{"GT string", "Name", cond(dynamodb.CmpOpGT, "go"), true},
+		{"GE binary is unsigned", "Data", cond(dynamodb.CmpOpGE, []byte{0x01, 0x02, 0x80}), true},
+		{"LT missing", "Missing", cond(dynamodb.CmpOpLT, 1), false},
+		{"LT different type", "Name", cond(dynamodb.CmpOpLT, 1), false},
+		{"NULL", "Missing", cond(dynamodb.CmpOpNull), true},
+		{"NULL is not NULL type", "Empty", cond(dynamodb.CmpOpNull), false},
+		{"NOT_NULL", "Name", cond(dynamodb.CmpOpNotNull), true},
+		{"EQ map by value", "Doc", cond(dynamodb.CmpOpEQ, map[string]dynamodb.AttributeValue{"Count": {Type: dynamodb.TypeNumber, Data: []dynamodb.AttributeData{"1.0"}}}), true},
+		{"EQ map with other keys", "Doc", cond(dynamodb.CmpOpEQ, map[string]dynamodb.AttributeValue{"Other": dynamodb.NewNumber(1)}), false},
+		{"EQ list in order", "List", cond(dynamodb.CmpOpEQ, []dynamodb.AttributeValue{dynamodb.NewString("a"), dynamodb.NewNumber(1)}), true},
+		{"NE list in other order", "List", cond(dynamodb.CmpOpNE, []dynamodb.AttributeValue{dynamodb.NewNumber(1), dynamodb.NewString("a")}), true},
+		{"CONTAINS substring", "Name", cond(dynamodb.CmpOpContains, "amz"), true},
+		{"CONTAINS member", "Tags", cond(dynamodb.CmpOpContains, "b"), true},
+		{"CONTAINS number member", "Scores", cond(dynamodb.CmpOpContains, 2), true},
+		{"CONTAINS bytes", "Data", cond(dynamodb.CmpOpContains, []byte{0x02}), true},
+		{"CONTAINS list element", "List", cond(dynamodb.CmpOpContains, 1), true},
+		{"NOT_CONTAINS list element", "List", cond(dynamodb.CmpOpNotContains, "b"), true},
+		{"NOT_CONTAINS", "Tags", cond(dynamodb.CmpOpNotContains, "c"), true},
+		{"NOT_CONTAINS missing", "Missing", cond(dynamodb.CmpOpNotContains, "c"), true},
+		{"BEGINS_WITH", "Name", cond(dynamodb.CmpOpBeginsWith, "go"), true},
+		{"BEGINS_WITH binary", "Data", cond(dynamodb.CmpOpBeginsWith, []byte{0x01}), true},
+		{"IN", "Count", cond(dynamodb.CmpOpIn, 1, 10, 100), true},
+		{"IN none", "Name", cond(dynamodb.CmpOpIn, "a", "b"), false},
+		{"BETWEEN inclusive", "Count", cond(dynamodb.CmpOpBetween, 5, 10), true},
+		{"BETWEEN out of range", "Count", cond(dynamodb.CmpOpBetween, 11, 20), false},
+	} {
+		ok, err := dynamodb.Evaluate(item, map[string]dynamodb.Condition{tc.attr: tc.cond}, "")
+		if assert.NoError(t, err, tc.name) {
+			assert.Equal(t, tc.expect, ok, tc.name)
+		}
+	}
+}
+
+func TestEvaluate_ConditionalOperator(t *testing.T) {
+	item := dynamodb.Item{"Name": dynamodb.NewString("goamz")}
+	filter := map[string]dynamodb.Condition{
+		"Name":    cond(dynamodb.CmpOpEQ, "goamz"),
+		"Missing": cond(dynamodb.CmpOpNotNull),
+	}
+
+	for op, expect := range map[dynamodb.ConditionalOperator]bool{
+		"":                 false,
+		dynamodb.CondOpAnd: false,
+		dynamodb.CondOpOr:  true,
+	} {
+		ok, err := dynamodb.Evaluate(item, filter, op)
+		if assert.NoError(t, err, op) {
+			assert.Equal(t, expect, ok, op)
+		}
+	}
+
+	ok, err := dynamodb.Evaluate(item, nil, "")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = dynamodb.Evaluate(item, filter, "XOR")
+	assert.True(t, errors.Is(err, dynamodb.ErrInvalidCondition))
+}
+
+func TestEvaluate_InvalidCondition(t *testing.T) {
+	for name, c := range map[string]dynamodb.Condition{
+		"NULL with argument":         cond(dynamodb.CmpOpNull, 1),
+		"EQ without argument":        cond(dynamodb.CmpOpEQ),
+		"BETWEEN with 1 argument":    cond(dynamodb.CmpOpBetween, 1),
+		"BETWEEN reversed":           cond(dynamodb.CmpOpBetween, 10, 1),
+		"BETWEEN mixed types":        cond(dynamodb.CmpOpBetween, 1, "10"),
+		"LT with set":                cond(dynamodb.CmpOpLT, []string{"a"}),
+		"BEGINS_WITH number":         cond(dynamodb.CmpOpBeginsWith, 1),
+		"IN without argument":        cond(dynamodb.CmpOpIn),
+		"invalid number":             {ComparisonOperator: dynamodb.CmpOpEQ, AttributeValueList: []dynamodb.AttributeValue{{Type: dynamodb.TypeNumber, Data: []dynamodb.AttributeData{"ten"}}}},
+		"unknown ComparisonOperator": {ComparisonOperator: "LIKE"},
+	} {
+		// the condition is rejected even if the attribute does not exist
+		_, err := dynamodb.Evaluate(dynamodb.Item{}, map[string]dynamodb.Condition{"Attr": c}, "")
+		assert.True(t, errors.Is(err, dynamodb.ErrInvalidCondition), "%s: %v", name, err)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	for _, tc := range []struct {
+		a, b   dynamodb.AttributeValue
+		expect int
+		ok     bool
+	}{
+		{dynamodb.NewNumber(9), dynamodb.NewNumber(10), -1, true},
+		{dynamodb.NewString("9"), dynamodb.NewString("10"), 1, true},
+		{mustAttributeValue([]byte{0x80}), mustAttributeValue([]byte{0x7f}), 1, true},
+		{mustAttributeValue(1.50), mustAttributeValue("1.5"), 0, false},
+		{dynamodb.NewStringSet("a"), dynamodb.NewStringSet("a"), 0, false},
+	} {
+		c, ok := dynamodb.Compare(tc.a, tc.b)
+		assert.Equal(t, tc.ok, ok, "%v %v", tc.a, tc.b)
+		assert.Equal(t, tc.expect, c, "%v %v", tc.a, tc.b)
+	}
+
+}
+
+func TestParseNumber(t *testing.T) {
+	for _, tc := range []struct {
+		number string
+		expect string
+	}{
+		{"1.50", "3/2"},
+		{"-0", "0"},
+		{"0.001", "1/1000"},
+		{"12E+2", "1200"},
+		{"1e-130", "1/1" + strings.Repeat("0", 130)},
+		{"9.9999999999999999999999999999999999999E+125", "99999999999999999999999999999999999999" + strings.Repeat("0", 88)},
+		{"100000000000000000000000000000000000000000", "1" + strings.Repeat("0", 41)},
+	} {
+		r, ok := dynamodb.ParseNumber(dynamodb.AttributeData(tc.number))
+		if assert.True(t, ok, tc.number) {
+			assert.Equal(t, tc.expect, r.RatString(), tc.number)
+		}
+	}
+
+	for _, number := range []string{
+		"",
+		"ten",
+		"0x10",
+		"0b11",
+		"0o7",
+		"1/3",
+		"010",
+		" 5 ",
+		"5 ",
+		"+5",
+		".5",
+		"5.",
+		"1_000",
+		"1e",
+		"Inf",
+		"NaN",
+		// 39 significant digits
+		"123456789012345678901234567890123456789",
+		"1.23456789012345678901234567890123456789",
+		// out of range
+		"1e126",
+		"1e-131",
+		"0.1e-130",
+		"1e99999999999999999999",
+	} {
+		_, ok := dynamodb.ParseNumber(dynamodb.AttributeData(number))
+		assert.False(t, ok, "%q must be rejected", number)
+	}
+}
+
+func mustAttributeValue(v interface{}) dynamodb.AttributeValue {
+	av, err := dynamodb.NewAttributeValue(v)
+	if err != nil {
+		panic(err)
+	}
+	return av
+}
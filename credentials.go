@@ -0,0 +1,530 @@
+package dynamodb
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNoCredentials = errors.New("dynamodb: no valid credentials found")
+)
+
+const (
+	// DefaultCredentialsExpiryWindow is how early CredentialsCache refreshes expiring credentials.
+	DefaultCredentialsExpiryWindow = 5 * time.Minute
+
+	// DefaultCredentialsRetryInterval is how long CredentialsCache returns the last failure
+	// before it asks the provider again.
+	DefaultCredentialsRetryInterval = 10 * time.Second
+
+	// DefaultInstanceMetadataEndpoint is the endpoint of the instance metadata service.
+	DefaultInstanceMetadataEndpoint = "http://169.254.169.254"
+
+	// DefaultContainerCredentialsHost is the host which serves AWS_CONTAINER_CREDENTIALS_RELATIVE_URI.
+	DefaultContainerCredentialsHost = "http://169.254.170.2"
+
+	defaultMetadataTimeout = time.Second
+)
+
+// Credentials is a set of AWS credentials.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// Expires is when the credentials expire. Zero means they never expire.
+	Expires time.Time
+}
+
+// Auth is a set of static credentials.
+type Auth struct {
+	AccessKey string
+	SecretKey string
+	Token     string
+}
+
+// CredentialsProvider retrieves credentials to sign requests.
+type CredentialsProvider interface {
+	Retrieve(ctx context.Context) (Credentials, error)
+}
+
+// StaticCredentials provides the fixed credentials.
+type StaticCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+func (s StaticCredentials) Retrieve(ctx context.Context) (Credentials, error) {
+	if s.AccessKeyID == "" || s.SecretAccessKey == "" {
+		return Credentials{}, fmt.Errorf("%w: static credentials are empty", ErrNoCredentials)
+	}
+	return Credentials{
+		AccessKeyID:     s.AccessKeyID,
+		SecretAccessKey: s.SecretAccessKey,
+		SessionToken:    s.SessionToken,
+	}, nil
+}
+
+// EnvCredentials provides credentials from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN.
+// AWS_ACCESS_KEY, AWS_SECRET_KEY and AWS_SECURITY_TOKEN are also read for compatibility.
+type EnvCredentials struct{}
+
+func (EnvCredentials) Retrieve(ctx context.Context) (Credentials, error) {
+	creds := Credentials{
+		AccessKeyID:     getenv("AWS_ACCESS_KEY_ID", "AWS_ACCESS_KEY"),
+		SecretAccessKey: getenv("AWS_SECRET_ACCESS_KEY", "AWS_SECRET_KEY"),
+		SessionToken:    getenv("AWS_SESSION_TOKEN", "AWS_SECURITY_TOKEN"),
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return Credentials{}, fmt.Errorf("%w: AWS_ACCESS_KEY_ID or AWS_SECRET_ACCESS_KEY is not set", ErrNoCredentials)
+	}
+	return creds, nil
+}
+
+func getenv(keys ...string) string {
+	for _, key := range keys {
+		if v := os.Getenv(key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// SharedCredentials provides credentials of a profile in the shared credentials file
+// and the shared config file.
+type SharedCredentials struct {
+	// Filename defaults to AWS_SHARED_CREDENTIALS_FILE or ~/.aws/credentials.
+	Filename string
+	// ConfigFilename defaults to AWS_CONFIG_FILE or ~/.aws/config.
+	ConfigFilename string
+	// Profile defaults to AWS_PROFILE or "default".
+	Profile string
+}
+
+func (s SharedCredentials) Retrieve(ctx context.Context) (Credentials, error) {
+	profile := s.Profile
+	if profile == "" {
+		profile = getenv("AWS_PROFILE", "AWS_DEFAULT_PROFILE")
+	}
+	if profile == "" {
+		profile = "default"
+	}
+
+	filename := s.Filename
+	if filename == "" {
+		filename = getenv("AWS_SHARED_CREDENTIALS_FILE")
+	}
+	if filename == "" {
+		filename = homePath(".aws", "credentials")
+	}
+	configFilename := s.ConfigFilename
+	if configFilename == "" {
+		configFilename = getenv("AWS_CONFIG_FILE")
+	}
+	if configFilename == "" {
+		configFilename = homePath(".aws", "config")
+	}
+
+	// the config file prefixes the sections with "profile " except the default
+	configSection := "profile " + profile
+	if profile == "default" {
+		configSection = profile
+	}
+
+	for _, f := range []struct {
+		name    string
+		section string
+	}{
+		{filename, profile},
+		{configFilename, configSection},
+	} {
+		if f.name == "" {
+			continue
+		}
+		sections, err := parseINIFile(f.name)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return Credentials{}, err
+		}
+		keys, ok := sections[f.section]
+		if !ok {
+			continue
+		}
+		creds := Credentials{
+			AccessKeyID:     keys["aws_access_key_id"],
+			SecretAccessKey: keys["aws_secret_access_key"],
+			SessionToken:    keys["aws_session_token"],
+		}
+		if creds.AccessKeyID != "" && creds.SecretAccessKey != "" {
+			return creds, nil
+		}
+	}
+	return Credentials{}, fmt.Errorf("%w: profile '%s' is not found in the shared files", ErrNoCredentials, profile)
+}
+
+func homePath(elem ...string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(append([]string{home}, elem...)...)
+}
+
+// parseINIFile parses the file into keys by section.
+func parseINIFile(name string) (map[string]map[string]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sections := map[string]map[string]string{}
+	var section map[string]string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		switch {
+		case line == "", strings.HasPrefix(line, "#"), strings.HasPrefix(line, ";"):
+			continue
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			name := strings.Join(strings.Fields(line[1:len(line)-1]), " ")
+			section = map[string]string{}
+			sections[name] = section
+		case section != nil:
+			kv := strings.SplitN(line, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			section[strings.ToLower(strings.TrimSpace(kv[0]))] = strings.TrimSpace(kv[1])
+		}
+	}
+	return sections, s.Err()
+}
+
+// ContainerCredentials provides credentials from the container credentials endpoint
+// such as the one of Amazon ECS.
+type ContainerCredentials struct {
+	// Endpoint defaults to AWS_CONTAINER_CREDENTIALS_FULL_URI or
+	// AWS_CONTAINER_CREDENTIALS_RELATIVE_URI on DefaultContainerCredentialsHost.
+	Endpoint string
+	// AuthorizationToken defaults to AWS_CONTAINER_AUTHORIZATION_TOKEN.
+	AuthorizationToken string
+	// HTTPClient defaults to a client with a short timeout.
+	HTTPClient *http.Client
+}
+
+func (c ContainerCredentials) Retrieve(ctx context.Context) (Credentials, error) {
+	endpoint := c.Endpoint
+	if endpoint == "" {
+		endpoint = os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	}
+	if endpoint == "" {
+		if uri := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); uri != "" {
+			endpoint = DefaultContainerCredentialsHost + uri
+		}
+	}
+	if endpoint == "" {
+		return Credentials{}, fmt.Errorf("%w: container credentials endpoint is not set", ErrNoCredentials)
+	}
+	token := c.AuthorizationToken
+	if token == "" {
+		token = os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	}
+
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return Credentials{}, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	body, err := getMetadata(ctx, metadataClient(c.HTTPClient), req)
+	if err != nil {
+		return Credentials{}, err
+	}
+	return decodeMetadataCredentials(body)
+}
+
+// InstanceMetadataCredentials provides credentials of the IAM role attached to the EC2 instance.
+// IMDSv2 is used and falls back to IMDSv1 if the token is unavailable.
+type InstanceMetadataCredentials struct {
+	// Endpoint defaults to DefaultInstanceMetadataEndpoint.
+	Endpoint string
+	// HTTPClient defaults to a client with a short timeout.
+	HTTPClient *http.Client
+}
+
+func (c InstanceMetadataCredentials) Retrieve(ctx context.Context) (Credentials, error) {
+	endpoint := c.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultInstanceMetadataEndpoint
+	}
+	client := metadataClient(c.HTTPClient)
+
+	var token string
+	if req, err := http.NewRequest("PUT", endpoint+"/latest/api/token", nil); err == nil {
+		req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "21600")
+		if body, err := getMetadata(ctx, client, req); err == nil {
+			token = string(body)
+		}
+	}
+
+	get := func(path string) ([]byte, error) {
+		req, err := http.NewRequest("GET", endpoint+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		if token != "" {
+			req.Header.Set("X-aws-ec2-metadata-token", token)
+		}
+		return getMetadata(ctx, client, req)
+	}
+
+	const path = "/latest/meta-data/iam/security-credentials/"
+	roles, err := get(path)
+	if err != nil {
+		return Credentials{}, err
+	}
+	role := strings.TrimSpace(strings.SplitN(string(roles), "\n", 2)[0])
+	if role == "" {
+		return Credentials{}, fmt.Errorf("%w: no IAM role is attached to the instance", ErrNoCredentials)
+	}
+	body, err := get(path + role)
+	if err != nil {
+		return Credentials{}, err
+	}
+	return decodeMetadataCredentials(body)
+}
+
+func metadataClient(c *http.Client) *http.Client {
+	if c != nil {
+		return c
+	}
+	return &http.Client{Timeout: defaultMetadataTimeout}
+}
+
+func getMetadata(ctx context.Context, client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoCredentials, err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, ErrFailedtoReadResponse
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s returned %s", ErrNoCredentials, req.URL, resp.Status)
+	}
+	return body, nil
+}
+
+func decodeMetadataCredentials(body []byte) (Credentials, error) {
+	var ret struct {
+		Code            string
+		Message         string
+		AccessKeyID     string `json:"AccessKeyId"`
+		SecretAccessKey string
+		Token           string
+		Expiration      time.Time
+	}
+	if err := json.Unmarshal(body, &ret); err != nil {
+		return Credentials{}, fmt.Errorf("%w: failed to decode credentials: %s", ErrNoCredentials, err)
+	}
+	if ret.Code != "" && ret.Code != "Success" {
+		return Credentials{}, fmt.Errorf("%w: %s: %s", ErrNoCredentials, ret.Code, ret.Message)
+	}
+	if ret.AccessKeyID == "" || ret.SecretAccessKey == "" {
+		return Credentials{}, fmt.Errorf("%w: credentials are empty", ErrNoCredentials)
+	}
+	return Credentials{
+		AccessKeyID:     ret.AccessKeyID,
+		SecretAccessKey: ret.SecretAccessKey,
+		SessionToken:    ret.Token,
+		Expires:         ret.Expiration,
+	}, nil
+}
+
+// ChainCredentials tries Providers in order and returns the first credentials found.
+type ChainCredentials struct {
+	Providers []CredentialsProvider
+}
+
+func (c ChainCredentials) Retrieve(ctx context.Context) (Credentials, error) {
+	var msgs []string
+	for _, p := range c.Providers {
+		creds, err := p.Retrieve(ctx)
+		if err == nil {
+			return creds, nil
+		}
+		if ctx.Err() != nil {
+			return Credentials{}, ctx.Err()
+		}
+		msgs = append(msgs, err.Error())
+	}
+	return Credentials{}, fmt.Errorf("%w in the chain: [%s]", ErrNoCredentials, strings.Join(msgs, "; "))
+}
+
+// CredentialsCache caches credentials from Provider until they are about to expire.
+// A failure is also cached for RetryInterval so that every request doesn't wait for
+// the providers which are unavailable such as the instance metadata.
+//
+// Only one caller refreshes the credentials at a time. The others wait for it, or get
+// the cached credentials if they are not expired yet, which are also returned when
+// the refresh fails. It is safe for concurrent use.
+type CredentialsCache struct {
+	Provider CredentialsProvider
+
+	// ExpiryWindow defaults to DefaultCredentialsExpiryWindow.
+	ExpiryWindow time.Duration
+
+	// RetryInterval defaults to DefaultCredentialsRetryInterval.
+	RetryInterval time.Duration
+
+	mu         sync.Mutex
+	creds      *Credentials
+	err        error
+	errExpires time.Time
+	refreshing *credentialsRefresh
+}
+
+// credentialsRefresh is a refresh in flight which the other callers wait for.
+type credentialsRefresh struct {
+	done     chan struct{}
+	creds    Credentials
+	err      error
+	canceled bool
+}
+
+// NewCredentialsCache returns CredentialsCache which caches credentials from p.
+func NewCredentialsCache(p CredentialsProvider) *CredentialsCache {
+	return &CredentialsCache{Provider: p}
+}
+
+func (c *CredentialsCache) Retrieve(ctx context.Context) (Credentials, error) {
+	for {
+		c.mu.Lock()
+		if c.creds != nil && !c.expired(*c.creds) {
+			creds := *c.creds
+			c.mu.Unlock()
+			return creds, nil
+		}
+		creds, valid := c.valid()
+		if c.err != nil && time.Now().Before(c.errExpires) {
+			err := c.err
+			c.mu.Unlock()
+			if valid {
+				return creds, nil
+			}
+			return Credentials{}, err
+		}
+		r := c.refreshing
+		if r == nil {
+			r = &credentialsRefresh{done: make(chan struct{})}
+			c.refreshing = r
+			c.mu.Unlock()
+			c.refresh(ctx, r)
+			return r.creds, r.err
+		}
+		c.mu.Unlock()
+
+		if valid {
+			return creds, nil
+		}
+		select {
+		case <-r.done:
+		case <-ctx.Done():
+			return Credentials{}, ctx.Err()
+		}
+		if !r.canceled {
+			return r.creds, r.err
+		}
+		// the caller which refreshed gave up, so refresh again
+	}
+}
+
+// refresh retrieves the credentials from Provider without holding the lock.
+func (c *CredentialsCache) refresh(ctx context.Context, r *credentialsRefresh) {
+	creds, err := c.Provider.Retrieve(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer close(r.done)
+	c.refreshing = nil
+
+	switch {
+	case err == nil:
+		c.creds = &creds
+		c.err = nil
+	case ctx.Err() != nil:
+		// the caller gave up, which says nothing about the providers
+		r.canceled = true
+	default:
+		interval := c.RetryInterval
+		if interval == 0 {
+			interval = DefaultCredentialsRetryInterval
+		}
+		c.err = err
+		c.errExpires = time.Now().Add(interval)
+	}
+	if err != nil {
+		if cached, valid := c.valid(); valid {
+			creds, err = cached, nil
+		}
+	}
+	r.creds, r.err = creds, err
+}
+
+// valid returns the cached credentials if they are not expired yet
+// although they may be within the expiry window.
+func (c *CredentialsCache) valid() (Credentials, bool) {
+	if c.creds == nil || !time.Now().Before(c.creds.Expires) {
+		return Credentials{}, false
+	}
+	return *c.creds, true
+}
+
+// Invalidate drops the cached credentials and failure so that the next Retrieve refreshes them.
+func (c *CredentialsCache) Invalidate() {
+	c.mu.Lock()
+	c.creds = nil
+	c.err = nil
+	c.mu.Unlock()
+}
+
+func (c *CredentialsCache) expired(creds Credentials) bool {
+	if creds.Expires.IsZero() {
+		return false
+	}
+	window := c.ExpiryWindow
+	if window == 0 {
+		window = DefaultCredentialsExpiryWindow
+	}
+	return !time.Now().Add(window).Before(creds.Expires)
+}
+
+// NewDefaultCredentials returns the cached chain of the environment variables,
+// the shared files, the container endpoint and the instance metadata.
+func NewDefaultCredentials() *CredentialsCache {
+	return NewCredentialsCache(ChainCredentials{
+		Providers: []CredentialsProvider{
+			EnvCredentials{},
+			SharedCredentials{},
+			ContainerCredentials{},
+			InstanceMetadataCredentials{},
+		},
+	})
+}
@@ -0,0 +1,381 @@
+package dynamodb_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nabeken/goamz-dynamodb"
+)
+
+func TestEnvCredentials(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_ACCESS_KEY", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	t.Setenv("AWS_SECRET_KEY", "")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_SECURITY_TOKEN", "")
+
+	_, err := dynamodb.EnvCredentials{}.Retrieve(context.Background())
+	assert.True(t, errors.Is(err, dynamodb.ErrNoCredentials))
+
+	t.Setenv("AWS_ACCESS_KEY", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "SECRET")
+	t.Setenv("AWS_SESSION_TOKEN", "TOKEN")
+	creds, err := dynamodb.EnvCredentials{}.Retrieve(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, dynamodb.Credentials{
+			AccessKeyID:     "AKID",
+			SecretAccessKey: "SECRET",
+			SessionToken:    "TOKEN",
+		}, creds)
+	}
+}
+
+func TestSharedCredentials(t *testing.T) {
+	dir := t.TempDir()
+	credentials := filepath.Join(dir, "credentials")
+	config := filepath.Join(dir, "config")
+	assert.NoError(t, ioutil.WriteFile(credentials, []byte(`
+# comment
+[default]
+aws_access_key_id = DEFAULT_KEY
+aws_secret_access_key = DEFAULT_SECRET
+
+[dev]
+aws_access_key_id=DEV_KEY
+aws_secret_access_key=DEV_SECRET
+aws_session_token=DEV_TOKEN
+`), 0600))
+	assert.NoError(t, ioutil.WriteFile(config, []byte(`
+[profile  prod]
+region = us-east-1
+aws_access_key_id = PROD_KEY
+aws_secret_access_key = PROD_SECRET
+`), 0600))
+
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_DEFAULT_PROFILE", "")
+
+	for _, tc := range []struct {
+		profile string
+		expect  dynamodb.Credentials
+	}{
+		{"", dynamodb.Credentials{AccessKeyID: "DEFAULT_KEY", SecretAccessKey: "DEFAULT_SECRET"}},
+		{"dev", dynamodb.Credentials{AccessKeyID: "DEV_KEY", SecretAccessKey: "DEV_SECRET", SessionToken: "DEV_TOKEN"}},
+		{"prod", dynamodb.Credentials{AccessKeyID: "PROD_KEY", SecretAccessKey: "PROD_SECRET"}},
+	} {
+		p := dynamodb.SharedCredentials{Filename: credentials, ConfigFilename: config, Profile: tc.profile}
+		creds, err := p.Retrieve(context.Background())
+		if assert.NoError(t, err, tc.profile) {
+			assert.Equal(t, tc.expect, creds, tc.profile)
+		}
+	}
+
+	t.Setenv("AWS_PROFILE", "dev")
+	creds, err := dynamodb.SharedCredentials{Filename: credentials, ConfigFilename: config}.Retrieve(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, "DEV_KEY", creds.AccessKeyID)
+	}
+
+	_, err = dynamodb.SharedCredentials{Filename: credentials, ConfigFilename: config, Profile: "missing"}.Retrieve(context.Background())
+	assert.True(t, errors.Is(err, dynamodb.ErrNoCredentials))
+}
+
+func newInstanceMetadataServer(t *testing.T, expiration time.Time, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "PUT" && r.URL.Path == "/latest/api/token":
+			assert.Equal(t, "21600", r.Header.Get("X-aws-ec2-metadata-token-ttl-seconds"))
+			w.Write([]byte("IMDS_TOKEN"))
+			return
+		case r.Header.Get("X-aws-ec2-metadata-token") != "IMDS_TOKEN":
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/":
+			w.Write([]byte("ROLE\n"))
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/ROLE":
+			atomic.AddInt32(requests, 1)
+			w.Write([]byte(`{
+  "Code": "Success",
+  "Type": "AWS-HMAC",
+  "AccessKeyId": "IMDS_KEY",
+  "SecretAccessKey": "IMDS_SECRET",
+  "Token": "IMDS_SESSION",
+  "Expiration": "` + expiration.UTC().Format(time.RFC3339) + `"
+}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestInstanceMetadataCredentials(t *testing.T) {
+	expiration := time.Now().Add(time.Hour).Truncate(time.Second)
+	var requests int32
+	ts := newInstanceMetadataServer(t, expiration, &requests)
+	defer ts.Close()
+
+	creds, err := dynamodb.InstanceMetadataCredentials{Endpoint: ts.URL}.Retrieve(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, "IMDS_KEY", creds.AccessKeyID)
+		assert.Equal(t, "IMDS_SECRET", creds.SecretAccessKey)
+		assert.Equal(t, "IMDS_SESSION", creds.SessionToken)
+		assert.True(t, expiration.Equal(creds.Expires))
+	}
+}
+
+func TestContainerCredentials(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/credentials/ID" || r.Header.Get("Authorization") != "AUTH" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"AccessKeyId":"ECS_KEY","SecretAccessKey":"ECS_SECRET","Token":"ECS_TOKEN","Expiration":"2030-01-01T00:00:00Z"}`))
+	}))
+	defer ts.Close()
+
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "")
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", "")
+	_, err := dynamodb.ContainerCredentials{}.Retrieve(context.Background())
+	assert.True(t, errors.Is(err, dynamodb.ErrNoCredentials))
+
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", ts.URL+"/v2/credentials/ID")
+	t.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN", "AUTH")
+	creds, err := dynamodb.ContainerCredentials{}.Retrieve(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, "ECS_KEY", creds.AccessKeyID)
+		assert.Equal(t, "ECS_TOKEN", creds.SessionToken)
+		assert.Equal(t, 2030, creds.Expires.Year())
+	}
+}
+
+func TestChainCredentials_Refresh(t *testing.T) {
+	// the credentials expire within the expiry window so every Retrieve refreshes them
+	var requests int32
+	ts := newInstanceMetadataServer(t, time.Now().Add(time.Minute), &requests)
+	defer ts.Close()
+
+	cache := dynamodb.NewCredentialsCache(dynamodb.ChainCredentials{
+		Providers: []dynamodb.CredentialsProvider{
+			dynamodb.StaticCredentials{},
+			dynamodb.InstanceMetadataCredentials{Endpoint: ts.URL},
+		},
+	})
+	for i := 0; i < 2; i++ {
+		creds, err := cache.Retrieve(context.Background())
+		if assert.NoError(t, err) {
+			assert.Equal(t, "IMDS_KEY", creds.AccessKeyID)
+		}
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	// the credentials are cached outside the expiry window
+	cache.ExpiryWindow = time.Second
+	for i := 0; i < 2; i++ {
+		_, err := cache.Retrieve(context.Background())
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	cache.Invalidate()
+	_, err := cache.Retrieve(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+
+	_, err = dynamodb.ChainCredentials{
+		Providers: []dynamodb.CredentialsProvider{dynamodb.StaticCredentials{}},
+	}.Retrieve(context.Background())
+	assert.True(t, errors.Is(err, dynamodb.ErrNoCredentials))
+}
+
+type countingCredentials struct {
+	calls int
+	err   error
+}
+
+func (c *countingCredentials) Retrieve(ctx context.Context) (dynamodb.Credentials, error) {
+	c.calls++
+	if c.err != nil {
+		return dynamodb.Credentials{}, c.err
+	}
+	return dynamodb.Credentials{AccessKeyID: "KEY", SecretAccessKey: "SECRET"}, nil
+}
+
+func TestCredentialsCache_Failure(t *testing.T) {
+	p := &countingCredentials{err: dynamodb.ErrNoCredentials}
+	cache := dynamodb.NewCredentialsCache(p)
+	cache.RetryInterval = 50 * time.Millisecond
+
+	// the failure is cached for RetryInterval
+	for i := 0; i < 3; i++ {
+		_, err := cache.Retrieve(context.Background())
+		assert.True(t, errors.Is(err, dynamodb.ErrNoCredentials))
+	}
+	assert.Equal(t, 1, p.calls)
+
+	time.Sleep(60 * time.Millisecond)
+	p.err = nil
+	creds, err := cache.Retrieve(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, "KEY", creds.AccessKeyID)
+	}
+	assert.Equal(t, 2, p.calls)
+
+	// Invalidate drops the failure too
+	p.err = dynamodb.ErrNoCredentials
+	cache.Invalidate()
+	_, err = cache.Retrieve(context.Background())
+	assert.Error(t, err)
+	cache.Invalidate()
+	p.err = nil
+	_, err = cache.Retrieve(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 4, p.calls)
+
+	// a canceled context is not cached
+	cache.Invalidate()
+	p.err = context.Canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = cache.Retrieve(ctx)
+	assert.Error(t, err)
+	p.err = nil
+	_, err = cache.Retrieve(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 6, p.calls)
+}
+
+// slowCredentials blocks Retrieve until release is closed.
+type slowCredentials struct {
+	calls   int32
+	release chan struct{}
+	expires time.Time
+	err     error
+}
+
+func (c *slowCredentials) Retrieve(ctx context.Context) (dynamodb.Credentials, error) {
+	n := atomic.AddInt32(&c.calls, 1)
+	<-c.release
+	if c.err != nil {
+		return dynamodb.Credentials{}, c.err
+	}
+	return dynamodb.Credentials{AccessKeyID: fmt.Sprintf("KEY%d", n), SecretAccessKey: "SECRET", Expires: c.expires}, nil
+}
+
+func TestCredentialsCache_SingleRefresh(t *testing.T) {
+	p := &slowCredentials{release: make(chan struct{})}
+	cache := dynamodb.NewCredentialsCache(p)
+
+	var wg sync.WaitGroup
+	keys := make([]string, 10)
+	for i := range keys {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			creds, err := cache.Retrieve(context.Background())
+			assert.NoError(t, err)
+			keys[i] = creds.AccessKeyID
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(p.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&p.calls))
+	for _, key := range keys {
+		assert.Equal(t, "KEY1", key)
+	}
+
+	// a waiter gives up with its own context
+	cache.Invalidate()
+	p.release = make(chan struct{})
+	go cache.Retrieve(context.Background())
+	time.Sleep(20 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := cache.Retrieve(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	close(p.release)
+}
+
+func TestCredentialsCache_RefreshUnexpired(t *testing.T) {
+	// the credentials are within the expiry window but not expired yet
+	p := &slowCredentials{release: make(chan struct{}), expires: time.Now().Add(time.Minute)}
+	close(p.release)
+	cache := dynamodb.NewCredentialsCache(p)
+	cache.RetryInterval = time.Hour
+
+	creds, err := cache.Retrieve(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, "KEY1", creds.AccessKeyID)
+	}
+
+	// the others get the cached credentials without waiting for a slow refresh
+	p.release = make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		creds, err := cache.Retrieve(context.Background())
+		if assert.NoError(t, err) {
+			assert.Equal(t, "KEY2", creds.AccessKeyID)
+		}
+	}()
+	time.Sleep(20 * time.Millisecond)
+	start := time.Now()
+	creds, err = cache.Retrieve(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, "KEY1", creds.AccessKeyID)
+	}
+	assert.True(t, time.Since(start) < 10*time.Millisecond, "Retrieve must not wait for the refresh")
+	close(p.release)
+	<-done
+
+	// a failed refresh keeps the credentials until they expire
+	p.err = dynamodb.ErrNoCredentials
+	for i := 0; i < 2; i++ {
+		creds, err = cache.Retrieve(context.Background())
+		if assert.NoError(t, err) {
+			assert.Equal(t, "KEY2", creds.AccessKeyID)
+		}
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&p.calls), "the failure must be cached")
+}
+
+func TestClient_Credentials(t *testing.T) {
+	var header http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		w.Write([]byte(`{"TableNames":[]}`))
+	}))
+	defer ts.Close()
+
+	c := &dynamodb.Client{
+		Region:            dynamodb.Region{DynamoDBEndpoint: ts.URL},
+		DisableCRC32Check: true,
+		Credentials: dynamodb.StaticCredentials{
+			AccessKeyID:     "PROVIDED_KEY",
+			SecretAccessKey: "PROVIDED_SECRET",
+			SessionToken:    "PROVIDED_TOKEN",
+		},
+	}
+	_, err := c.ListTables(nil)
+	assert.NoError(t, err)
+	assert.Contains(t, header.Get("Authorization"), "Credential=PROVIDED_KEY/")
+	assert.Equal(t, "PROVIDED_TOKEN", header.Get("X-Amz-Security-Token"))
+
+	c.Credentials = dynamodb.StaticCredentials{}
+	c.Retryer = dynamodb.NoOpRetryer{}
+	_, err = c.ListTables(nil)
+	assert.True(t, errors.Is(err, dynamodb.ErrNoCredentials))
+}
@@ -4,23 +4,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net"
+	"hash/crc32"
 	"net/http"
+	"strconv"
 	"strings"
-	"time"
-
-	"github.com/crowdmob/goamz/aws"
 )
 
 const apiVersion = "DynamoDB_20120810"
 
-var attempts = aws.AttemptStrategy{
-	Min:   5,
-	Total: 5 * time.Second,
-	Delay: 200 * time.Millisecond,
-}
-
 // Specific error constants
 var (
 	ErrNotFound                        = errors.New("dynamodb: item not found")
@@ -28,6 +19,8 @@ var (
 	ErrAtLeastOneAttributeRequired     = errors.New("dynamodb: at least one attribute is required")
 	ErrInconsistencyInTableDescription = errors.New("dynamodb: inconsistency found in TableDescriptionT")
 	ErrNotImplemented                  = errors.New("dynamodb: Not implemented")
+	ErrCRC32Mismatch                   = errors.New("dynamodb: CRC32 checksum of response does not match x-amz-crc32")
+	ErrNoResponse                      = errors.New("dynamodb: no HTTP response is set by the send stage")
 )
 
 type UnexpectedResponseError struct {
@@ -56,6 +49,10 @@ type Error struct {
 	Code string
 	// The human-oriented error message
 	Message string
+	// The request ID in x-amzn-RequestId header to quote to AWS support
+	RequestID string
+	// The raw response body
+	Body []byte
 }
 
 // UnmarshalJSON parses the JSON-encoded API error message data and
@@ -65,54 +62,134 @@ func (e *Error) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, ae); err != nil {
 		return err
 	}
-	e.Code = strings.SplitN(ae.Type, "#", 2)[1]
+	e.Code = errorCode(ae.Type)
 	e.Message = ae.Message
 	return nil
 }
 
+// errorCode extracts a code from "com.amazonaws.dynamodb.v20120810#ResourceNotFoundException"
+// or "ValidationException:http://internal.amazon.com/coral/com.amazon.coral.validate/".
+func errorCode(typ string) string {
+	if i := strings.LastIndex(typ, "#"); i >= 0 {
+		typ = typ[i+1:]
+	}
+	if i := strings.Index(typ, ":"); i >= 0 {
+		typ = typ[:i]
+	}
+	return strings.TrimSpace(typ)
+}
+
 func (e *Error) Error() string {
 	return "dynamodb: " + e.Code + ": " + e.Message
 }
 
-func NewError(r *http.Response, jsonBody []byte) error {
+// Is reports whether target is *Error with the same Code so that
+// errors.Is(err, ErrConditionalCheckFailed) matches any error with the code.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code != "" && t.Code == e.Code
+}
+
+// Error codes of the exceptions documented at
+// http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/CommonErrors.html
+const (
+	CodeConditionalCheckFailed          = "ConditionalCheckFailedException"
+	CodeInternalServerError             = "InternalServerError"
+	CodeInvalidSignature                = "InvalidSignatureException"
+	CodeItemCollectionSizeLimitExceeded = "ItemCollectionSizeLimitExceededException"
+	CodeLimitExceeded                   = "LimitExceededException"
+	CodeProvisionedThroughputExceeded   = "ProvisionedThroughputExceededException"
+	CodeRequestLimitExceeded            = "RequestLimitExceeded"
+	CodeResourceInUse                   = "ResourceInUseException"
+	CodeResourceNotFound                = "ResourceNotFoundException"
+	CodeThrottling                      = "ThrottlingException"
+	CodeTransactionCanceled             = "TransactionCanceledException"
+	CodeValidation                      = "ValidationException"
+)
+
+// Sentinel errors for the exceptions. Use errors.Is to test an error returned by Client,
+// and errors.As with *Error to get the details.
+var (
+	ErrConditionalCheckFailed          = &Error{Code: CodeConditionalCheckFailed}
+	ErrInternalServerError             = &Error{Code: CodeInternalServerError}
+	ErrInvalidSignature                = &Error{Code: CodeInvalidSignature}
+	ErrItemCollectionSizeLimitExceeded = &Error{Code: CodeItemCollectionSizeLimitExceeded}
+	ErrLimitExceeded                   = &Error{Code: CodeLimitExceeded}
+	ErrProvisionedThroughputExceeded   = &Error{Code: CodeProvisionedThroughputExceeded}
+	ErrRequestLimitExceeded            = &Error{Code: CodeRequestLimitExceeded}
+	ErrResourceInUse                   = &Error{Code: CodeResourceInUse}
+	ErrResourceNotFound                = &Error{Code: CodeResourceNotFound}
+	ErrThrottling                      = &Error{Code: CodeThrottling}
+	ErrTransactionCanceled             = &Error{Code: CodeTransactionCanceled}
+	ErrValidation                      = &Error{Code: CodeValidation}
+)
+
+// ErrorCode returns the DynamoDB error code of err or an empty string if err is not *Error.
+func ErrorCode(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return ""
+}
+
+// IsThrottle reports whether err is a throttling error such as ProvisionedThroughputExceededException.
+func IsThrottle(err error) bool {
+	return isThrottle(err)
+}
+
+// IsRetryable reports whether err is a throttling or transient error which DefaultRetryer retries.
+func IsRetryable(err error) bool {
+	return shouldRetry(err)
+}
+
+// maxErrorMessageLength limits the message taken from a non-JSON body such as an HTML page.
+const maxErrorMessageLength = 256
+
+// NewError builds *Error from a response. It never fails even if the body is not JSON
+// such as a proxy's HTML page; the code falls back to x-amzn-ErrorType header and
+// then to the HTTP status, and the message to the body itself.
+func NewError(r *http.Response, body []byte) *Error {
 	ddbError := &Error{
 		StatusCode: r.StatusCode,
 		Status:     r.Status,
+		RequestID:  r.Header.Get("X-Amzn-Requestid"),
+		Body:       body,
 	}
-	if err := json.Unmarshal(jsonBody, ddbError); err != nil {
-		return err
+	if err := json.Unmarshal(body, ddbError); err != nil {
+		msg := strings.TrimSpace(string(body))
+		if len(msg) > maxErrorMessageLength {
+			msg = msg[:maxErrorMessageLength] + "..."
+		}
+		ddbError.Message = msg
+	}
+	if ddbError.Code == "" {
+		ddbError.Code = errorCode(r.Header.Get("X-Amzn-Errortype"))
+	}
+	if ddbError.Code == "" {
+		ddbError.Code = strings.Replace(http.StatusText(r.StatusCode), " ", "", -1)
+	}
+	if ddbError.Message == "" {
+		ddbError.Message = r.Status
 	}
 	return ddbError
 }
 
-// Based on github.com/crowdmob/goamz/s3
-func shouldRetry(err error) bool {
-	if err == nil {
-		return false
+// verifyCRC32 verifies body with the CRC32 checksum in x-amz-crc32 header.
+// A response without the header passes as proxies may strip it.
+func verifyCRC32(r *http.Response, body []byte) error {
+	h := r.Header.Get("X-Amz-Crc32")
+	if h == "" {
+		return nil
 	}
-	switch err {
-	case io.ErrUnexpectedEOF, io.EOF:
-		return true
+	expected, err := strconv.ParseUint(h, 10, 32)
+	if err != nil {
+		return ErrCRC32Mismatch
 	}
-	switch e := err.(type) {
-	case *net.DNSError:
-		return true
-	case *net.OpError:
-		switch e.Op {
-		case "read", "write":
-			return true
-		}
-	case *Error:
-		switch e.Code {
-		case "InternalError", "ProvisionedThroughputExceededException":
-			return true
-		}
-		switch e.StatusCode {
-		case 500, 503:
-			return true
-		}
+	if crc32.ChecksumIEEE(body) != uint32(expected) {
+		return ErrCRC32Mismatch
 	}
-	return false
+	return nil
 }
 
 func target(name string) string {
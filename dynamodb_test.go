@@ -1,33 +1,52 @@
 package dynamodb_test
 
 import (
+	"errors"
 	"flag"
+	"fmt"
+	"io"
 	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 
-	"github.com/crowdmob/goamz/aws"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/nabeken/goamz-dynamodb"
+	"github.com/nabeken/goamz-dynamodb/dynamodbtest"
 )
 
 const timeout = 3 * time.Minute
 
 var (
 	integration = flag.Bool("integration", false, "Enable integration tests against DynamoDB server")
-	provider    = flag.String("provider", "local", "Specify a DynamoDB provider. Default: local. [local|dynalite|amazon]")
+	provider    = flag.String("provider", "local", "Specify a DynamoDB provider. Default: local. [local|dynalite|memory|amazon]")
 )
 
 var (
-	dummyRegion = map[string]aws.Region{
-		"local":    aws.Region{DynamoDBEndpoint: "http://127.0.0.1:8000"},
-		"dynalite": aws.Region{DynamoDBEndpoint: "http://127.0.0.1:4567"},
-		"amazon":   aws.USEast,
+	providerEndpoints = map[string]string{
+		"local":    "http://127.0.0.1:8000",
+		"dynalite": "http://127.0.0.1:4567",
 	}
-	dummyAuth = aws.Auth{AccessKey: "DUMMY_KEY", SecretKey: "DUMMY_SECRET"}
+	dummyAuth = dynamodb.Auth{AccessKey: "DUMMY_KEY", SecretKey: "DUMMY_SECRET"}
 )
 
+var (
+	memoryServer     *dynamodbtest.Server
+	memoryServerOnce sync.Once
+)
+
+// memoryEndpoint starts the in-memory server shared by the suites for -provider=memory.
+func memoryEndpoint() string {
+	memoryServerOnce.Do(func() {
+		memoryServer = dynamodbtest.NewServer()
+	})
+	return memoryServer.URL
+}
+
 type actionHandler func(done chan struct{})
 
 func handleAction(action actionHandler) (done chan struct{}) {
@@ -170,21 +189,27 @@ func (s *DynamoDBCommonSuite) SetupDB() {
 
 	s.t.Logf("Performing Integration tests on %s...", *provider)
 
-	var auth aws.Auth
-	if *provider == "amazon" {
-		s.t.Log("Using REAL AMAZON SERVER")
-		awsAuth, err := aws.EnvAuth()
-		if err != nil {
-			log.Fatal(err)
-		}
-		auth = awsAuth
+	opts := []dynamodb.Option{dynamodb.WithRegion("us-east-1")}
+	endpoint, ok := providerEndpoints[*provider]
+	if *provider == "memory" {
+		endpoint, ok = memoryEndpoint(), true
+	}
+	if ok {
+		opts = append(opts,
+			dynamodb.WithEndpoint(endpoint),
+			dynamodb.WithCredentials(dynamodb.StaticCredentials{
+				AccessKeyID:     dummyAuth.AccessKey,
+				SecretAccessKey: dummyAuth.SecretKey,
+			}),
+		)
 	} else {
-		auth = dummyAuth
+		s.t.Log("Using REAL AMAZON SERVER")
 	}
-	s.c = &dynamodb.Client{
-		Auth:   auth,
-		Region: dummyRegion[*provider],
+	c, err := dynamodb.NewClient(opts...)
+	if err != nil {
+		log.Fatal(err)
 	}
+	s.c = c
 	// Ensure that the table does not exist
 	s.DeleteTable()
 
@@ -210,3 +235,121 @@ func doIntegrationTest(t *testing.T, suites ...suite.TestingSuite) {
 		suite.Run(t, suites[i])
 	}
 }
+
+func TestError_Is(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#ConditionalCheckFailedException","message":"The conditional request failed"}`))
+	}))
+	defer ts.Close()
+
+	c := &dynamodb.Client{
+		Auth:              dummyAuth,
+		Region:            dynamodb.Region{DynamoDBEndpoint: ts.URL},
+		DisableCRC32Check: true,
+	}
+	_, err := c.PutItem("TABLE", dynamodb.Item{"HashKey": dynamodb.NewString("HASH")}, nil)
+
+	assert.True(t, errors.Is(err, dynamodb.ErrConditionalCheckFailed))
+	assert.False(t, errors.Is(err, dynamodb.ErrValidation))
+
+	wrapped := fmt.Errorf("failed to put: %w", err)
+	assert.True(t, errors.Is(wrapped, dynamodb.ErrConditionalCheckFailed))
+
+	var ddbErr *dynamodb.Error
+	if assert.True(t, errors.As(wrapped, &ddbErr)) {
+		assert.Equal(t, 400, ddbErr.StatusCode)
+		assert.Equal(t, "The conditional request failed", ddbErr.Message)
+	}
+	assert.Equal(t, dynamodb.CodeConditionalCheckFailed, dynamodb.ErrorCode(wrapped))
+	assert.Equal(t, "", dynamodb.ErrorCode(errors.New("dynamodb: error")))
+}
+
+func TestIsThrottle(t *testing.T) {
+	for _, code := range []string{
+		dynamodb.CodeThrottling,
+		dynamodb.CodeProvisionedThroughputExceeded,
+		dynamodb.CodeRequestLimitExceeded,
+	} {
+		err := fmt.Errorf("wrapped: %w", &dynamodb.Error{StatusCode: 400, Code: code})
+		assert.True(t, dynamodb.IsThrottle(err), code)
+		assert.True(t, dynamodb.IsRetryable(err), code)
+	}
+	assert.False(t, dynamodb.IsThrottle(&dynamodb.Error{StatusCode: 500, Code: dynamodb.CodeInternalServerError}))
+	assert.False(t, dynamodb.IsThrottle(nil))
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, dynamodb.IsRetryable(&dynamodb.Error{StatusCode: 500, Code: dynamodb.CodeInternalServerError}))
+	assert.True(t, dynamodb.IsRetryable(fmt.Errorf("wrapped: %w", io.ErrUnexpectedEOF)))
+	assert.False(t, dynamodb.IsRetryable(&dynamodb.Error{StatusCode: 400, Code: dynamodb.CodeConditionalCheckFailed}))
+	assert.False(t, dynamodb.IsRetryable(&dynamodb.Error{StatusCode: 400, Code: dynamodb.CodeItemCollectionSizeLimitExceeded}))
+	assert.False(t, dynamodb.IsRetryable(nil))
+}
+
+func TestNewError(t *testing.T) {
+	newResponse := func(statusCode int, header map[string]string) *http.Response {
+		r := &http.Response{
+			StatusCode: statusCode,
+			Status:     fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+			Header:     http.Header{},
+		}
+		for k, v := range header {
+			r.Header.Set(k, v)
+		}
+		return r
+	}
+
+	for _, tc := range []struct {
+		name      string
+		resp      *http.Response
+		body      string
+		code      string
+		message   string
+		requestID string
+	}{
+		{
+			name:      "JSON",
+			resp:      newResponse(400, map[string]string{"x-amzn-RequestId": "REQUEST_ID"}),
+			body:      `{"__type":"com.amazonaws.dynamodb.v20120810#ResourceNotFoundException","message":"Requested resource not found"}`,
+			code:      "ResourceNotFoundException",
+			message:   "Requested resource not found",
+			requestID: "REQUEST_ID",
+		},
+		{
+			name:    "__type without #",
+			resp:    newResponse(400, nil),
+			body:    `{"__type":"ValidationException","Message":"1 validation error detected"}`,
+			code:    "ValidationException",
+			message: "1 validation error detected",
+		},
+		{
+			name:    "empty __type",
+			resp:    newResponse(400, map[string]string{"x-amzn-ErrorType": "ValidationException:http://internal.amazon.com/coral/com.amazon.coral.validate/"}),
+			body:    `{}`,
+			code:    "ValidationException",
+			message: "400 Bad Request",
+		},
+		{
+			name:    "HTML",
+			resp:    newResponse(502, nil),
+			body:    "<html><body>Bad Gateway</body></html>\n",
+			code:    "BadGateway",
+			message: "<html><body>Bad Gateway</body></html>",
+		},
+		{
+			name:    "empty body",
+			resp:    newResponse(413, nil),
+			body:    "",
+			code:    "RequestEntityTooLarge",
+			message: "413 Request Entity Too Large",
+		},
+	} {
+		err := dynamodb.NewError(tc.resp, []byte(tc.body))
+		assert.Equal(t, tc.resp.StatusCode, err.StatusCode, tc.name)
+		assert.Equal(t, tc.code, err.Code, tc.name)
+		assert.Equal(t, tc.message, err.Message, tc.name)
+		assert.Equal(t, tc.requestID, err.RequestID, tc.name)
+		assert.Equal(t, tc.body, string(err.Body), tc.name)
+	}
+}
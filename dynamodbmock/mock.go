@@ -0,0 +1,419 @@
+// Package dynamodbmock provides a mock of dynamodb.API which records calls and
+// returns canned results.
+//
+//	m := dynamodbmock.New()
+//	m.Return("GetItem", &dynamodb.GetItemResult{Item: item}, nil)
+//	// run code which depends on dynamodb.API with m
+//	calls := m.CallsOf("GetItem")
+package dynamodbmock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/nabeken/goamz-dynamodb"
+)
+
+var (
+	ErrNoResult   = errors.New("dynamodbmock: no result is set")
+	ErrResultType = errors.New("dynamodbmock: result has a wrong type")
+)
+
+// Call is a recorded call of an operation.
+type Call struct {
+	Context context.Context
+	// Operation is the name of the operation such as "GetItem" for both GetItem and GetItemWithContext.
+	Operation string
+	// Args are the arguments following the context.
+	Args []interface{}
+}
+
+type result struct {
+	value interface{}
+	err   error
+}
+
+// Mock implements dynamodb.API. It is safe for concurrent use.
+type Mock struct {
+	mu      sync.Mutex
+	calls   []Call
+	results map[string][]result
+}
+
+var _ dynamodb.API = (*Mock)(nil)
+
+// New returns an empty Mock.
+func New() *Mock {
+	return &Mock{results: map[string][]result{}}
+}
+
+// Return queues the result of the next call of operation such as "GetItem".
+// value must be the result type of the operation such as *dynamodb.GetItemResult, or nil.
+// A value of another type fails the call with ErrResultType.
+// The last result is returned repeatedly once the queue has only one result.
+// A call without any result fails with ErrNoResult.
+func (m *Mock) Return(operation string, value interface{}, err error) *Mock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.results[operation] = append(m.results[operation], result{value, err})
+	return m
+}
+
+// Calls returns all the recorded calls in order.
+func (m *Mock) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// CallsOf returns the recorded calls of operation in order.
+func (m *Mock) CallsOf(operation string) []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var calls []Call
+	for _, c := range m.calls {
+		if c.Operation == operation {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// Reset drops the recorded calls and the queued results.
+func (m *Mock) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = nil
+	m.results = map[string][]result{}
+}
+
+func (m *Mock) call(ctx context.Context, operation string, args ...interface{}) (interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, Call{Context: ctx, Operation: operation, Args: args})
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rs := m.results[operation]
+	if len(rs) == 0 {
+		return nil, fmt.Errorf("%w for %s", ErrNoResult, operation)
+	}
+	if len(rs) > 1 {
+		m.results[operation] = rs[1:]
+	}
+	return rs[0].value, rs[0].err
+}
+
+// resultTypeError reports a result queued by Return which is not the result type of operation.
+func resultTypeError(operation string, expected, actual interface{}) error {
+	return fmt.Errorf("%w for %s: expected %T but got %T", ErrResultType, operation, expected, actual)
+}
+
+func (m *Mock) BatchExecuteStatement(statements []dynamodb.BatchStatementRequest, bopt *dynamodb.BatchExecuteStatementOption) (*dynamodb.BatchExecuteStatementResult, error) {
+	return m.BatchExecuteStatementWithContext(context.Background(), statements, bopt)
+}
+
+func (m *Mock) BatchExecuteStatementWithContext(ctx context.Context, statements []dynamodb.BatchStatementRequest, bopt *dynamodb.BatchExecuteStatementOption) (*dynamodb.BatchExecuteStatementResult, error) {
+	v, err := m.call(ctx, "BatchExecuteStatement", statements, bopt)
+	if v == nil {
+		return nil, err
+	}
+	ret, ok := v.(*dynamodb.BatchExecuteStatementResult)
+	if !ok {
+		return nil, resultTypeError("BatchExecuteStatement", ret, v)
+	}
+	return ret, err
+}
+
+func (m *Mock) BatchGetItem(items map[string]dynamodb.KeysAndAttributes, bopt *dynamodb.BatchGetItemOption) (*dynamodb.BatchGetItemResult, error) {
+	return m.BatchGetItemWithContext(context.Background(), items, bopt)
+}
+
+func (m *Mock) BatchGetItemWithContext(ctx context.Context, items map[string]dynamodb.KeysAndAttributes, bopt *dynamodb.BatchGetItemOption) (*dynamodb.BatchGetItemResult, error) {
+	v, err := m.call(ctx, "BatchGetItem", items, bopt)
+	if v == nil {
+		return nil, err
+	}
+	ret, ok := v.(*dynamodb.BatchGetItemResult)
+	if !ok {
+		return nil, resultTypeError("BatchGetItem", ret, v)
+	}
+	return ret, err
+}
+
+func (m *Mock) BatchWriteItem(items map[string][]dynamodb.WriteRequest, bopt *dynamodb.BatchWriteItemOption) (*dynamodb.BatchWriteItemResult, error) {
+	return m.BatchWriteItemWithContext(context.Background(), items, bopt)
+}
+
+func (m *Mock) BatchWriteItemWithContext(ctx context.Context, items map[string][]dynamodb.WriteRequest, bopt *dynamodb.BatchWriteItemOption) (*dynamodb.BatchWriteItemResult, error) {
+	v, err := m.call(ctx, "BatchWriteItem", items, bopt)
+	if v == nil {
+		return nil, err
+	}
+	ret, ok := v.(*dynamodb.BatchWriteItemResult)
+	if !ok {
+		return nil, resultTypeError("BatchWriteItem", ret, v)
+	}
+	return ret, err
+}
+
+func (m *Mock) CreateTable(t *dynamodb.Table, topt *dynamodb.TableOption) (*dynamodb.CreateTableResult, error) {
+	return m.CreateTableWithContext(context.Background(), t, topt)
+}
+
+func (m *Mock) CreateTableWithContext(ctx context.Context, t *dynamodb.Table, topt *dynamodb.TableOption) (*dynamodb.CreateTableResult, error) {
+	v, err := m.call(ctx, "CreateTable", t, topt)
+	if v == nil {
+		return nil, err
+	}
+	ret, ok := v.(*dynamodb.CreateTableResult)
+	if !ok {
+		return nil, resultTypeError("CreateTable", ret, v)
+	}
+	return ret, err
+}
+
+func (m *Mock) DeleteItem(table string, key map[string]dynamodb.AttributeValue, dopt *dynamodb.DeleteItemOption) (*dynamodb.DeleteItemResult, error) {
+	return m.DeleteItemWithContext(context.Background(), table, key, dopt)
+}
+
+func (m *Mock) DeleteItemWithContext(ctx context.Context, table string, key map[string]dynamodb.AttributeValue, dopt *dynamodb.DeleteItemOption) (*dynamodb.DeleteItemResult, error) {
+	v, err := m.call(ctx, "DeleteItem", table, key, dopt)
+	if v == nil {
+		return nil, err
+	}
+	ret, ok := v.(*dynamodb.DeleteItemResult)
+	if !ok {
+		return nil, resultTypeError("DeleteItem", ret, v)
+	}
+	return ret, err
+}
+
+func (m *Mock) DeleteTable(table string) (*dynamodb.DeleteTableResult, error) {
+	return m.DeleteTableWithContext(context.Background(), table)
+}
+
+func (m *Mock) DeleteTableWithContext(ctx context.Context, table string) (*dynamodb.DeleteTableResult, error) {
+	v, err := m.call(ctx, "DeleteTable", table)
+	if v == nil {
+		return nil, err
+	}
+	ret, ok := v.(*dynamodb.DeleteTableResult)
+	if !ok {
+		return nil, resultTypeError("DeleteTable", ret, v)
+	}
+	return ret, err
+}
+
+func (m *Mock) DescribeLimits() (*dynamodb.DescribeLimitsResult, error) {
+	return m.DescribeLimitsWithContext(context.Background())
+}
+
+func (m *Mock) DescribeLimitsWithContext(ctx context.Context) (*dynamodb.DescribeLimitsResult, error) {
+	v, err := m.call(ctx, "DescribeLimits")
+	if v == nil {
+		return nil, err
+	}
+	ret, ok := v.(*dynamodb.DescribeLimitsResult)
+	if !ok {
+		return nil, resultTypeError("DescribeLimits", ret, v)
+	}
+	return ret, err
+}
+
+func (m *Mock) DescribeTable(table string) (*dynamodb.DescribeTableResult, error) {
+	return m.DescribeTableWithContext(context.Background(), table)
+}
+
+func (m *Mock) DescribeTableWithContext(ctx context.Context, table string) (*dynamodb.DescribeTableResult, error) {
+	v, err := m.call(ctx, "DescribeTable", table)
+	if v == nil {
+		return nil, err
+	}
+	ret, ok := v.(*dynamodb.DescribeTableResult)
+	if !ok {
+		return nil, resultTypeError("DescribeTable", ret, v)
+	}
+	return ret, err
+}
+
+func (m *Mock) ExecuteStatement(statement string, params []dynamodb.AttributeValue, eopt *dynamodb.ExecuteStatementOption) (*dynamodb.ExecuteStatementResult, error) {
+	return m.ExecuteStatementWithContext(context.Background(), statement, params, eopt)
+}
+
+func (m *Mock) ExecuteStatementWithContext(ctx context.Context, statement string, params []dynamodb.AttributeValue, eopt *dynamodb.ExecuteStatementOption) (*dynamodb.ExecuteStatementResult, error) {
+	v, err := m.call(ctx, "ExecuteStatement", statement, params, eopt)
+	if v == nil {
+		return nil, err
+	}
+	ret, ok := v.(*dynamodb.ExecuteStatementResult)
+	if !ok {
+		return nil, resultTypeError("ExecuteStatement", ret, v)
+	}
+	return ret, err
+}
+
+func (m *Mock) ExecuteTransaction(statements []dynamodb.ParameterizedStatement, eopt *dynamodb.ExecuteTransactionOption) (*dynamodb.ExecuteTransactionResult, error) {
+	return m.ExecuteTransactionWithContext(context.Background(), statements, eopt)
+}
+
+func (m *Mock) ExecuteTransactionWithContext(ctx context.Context, statements []dynamodb.ParameterizedStatement, eopt *dynamodb.ExecuteTransactionOption) (*dynamodb.ExecuteTransactionResult, error) {
+	v, err := m.call(ctx, "ExecuteTransaction", statements, eopt)
+	if v == nil {
+		return nil, err
+	}
+	ret, ok := v.(*dynamodb.ExecuteTransactionResult)
+	if !ok {
+		return nil, resultTypeError("ExecuteTransaction", ret, v)
+	}
+	return ret, err
+}
+
+func (m *Mock) GetItem(table string, key map[string]dynamodb.AttributeValue, gopt *dynamodb.GetItemOption) (*dynamodb.GetItemResult, error) {
+	return m.GetItemWithContext(context.Background(), table, key, gopt)
+}
+
+func (m *Mock) GetItemWithContext(ctx context.Context, table string, key map[string]dynamodb.AttributeValue, gopt *dynamodb.GetItemOption) (*dynamodb.GetItemResult, error) {
+	v, err := m.call(ctx, "GetItem", table, key, gopt)
+	if v == nil {
+		return nil, err
+	}
+	ret, ok := v.(*dynamodb.GetItemResult)
+	if !ok {
+		return nil, resultTypeError("GetItem", ret, v)
+	}
+	return ret, err
+}
+
+func (m *Mock) ListTables(lopt *dynamodb.ListTablesOption) (*dynamodb.ListTablesResult, error) {
+	return m.ListTablesWithContext(context.Background(), lopt)
+}
+
+func (m *Mock) ListTablesWithContext(ctx context.Context, lopt *dynamodb.ListTablesOption) (*dynamodb.ListTablesResult, error) {
+	v, err := m.call(ctx, "ListTables", lopt)
+	if v == nil {
+		return nil, err
+	}
+	ret, ok := v.(*dynamodb.ListTablesResult)
+	if !ok {
+		return nil, resultTypeError("ListTables", ret, v)
+	}
+	return ret, err
+}
+
+func (m *Mock) ListTagsOfResource(resourceArn string, lopt *dynamodb.ListTagsOfResourceOption) (*dynamodb.ListTagsOfResourceResult, error) {
+	return m.ListTagsOfResourceWithContext(context.Background(), resourceArn, lopt)
+}
+
+func (m *Mock) ListTagsOfResourceWithContext(ctx context.Context, resourceArn string, lopt *dynamodb.ListTagsOfResourceOption) (*dynamodb.ListTagsOfResourceResult, error) {
+	v, err := m.call(ctx, "ListTagsOfResource", resourceArn, lopt)
+	if v == nil {
+		return nil, err
+	}
+	ret, ok := v.(*dynamodb.ListTagsOfResourceResult)
+	if !ok {
+		return nil, resultTypeError("ListTagsOfResource", ret, v)
+	}
+	return ret, err
+}
+
+func (m *Mock) PutItem(table string, item dynamodb.Item, popt *dynamodb.PutItemOption) (*dynamodb.PutItemResult, error) {
+	return m.PutItemWithContext(context.Background(), table, item, popt)
+}
+
+func (m *Mock) PutItemWithContext(ctx context.Context, table string, item dynamodb.Item, popt *dynamodb.PutItemOption) (*dynamodb.PutItemResult, error) {
+	v, err := m.call(ctx, "PutItem", table, item, popt)
+	if v == nil {
+		return nil, err
+	}
+	ret, ok := v.(*dynamodb.PutItemResult)
+	if !ok {
+		return nil, resultTypeError("PutItem", ret, v)
+	}
+	return ret, err
+}
+
+func (m *Mock) Query(table string, conditions *dynamodb.KeyConditions, qopt *dynamodb.QueryOption) (*dynamodb.QueryResult, error) {
+	return m.QueryWithContext(context.Background(), table, conditions, qopt)
+}
+
+func (m *Mock) QueryWithContext(ctx context.Context, table string, conditions *dynamodb.KeyConditions, qopt *dynamodb.QueryOption) (*dynamodb.QueryResult, error) {
+	v, err := m.call(ctx, "Query", table, conditions, qopt)
+	if v == nil {
+		return nil, err
+	}
+	ret, ok := v.(*dynamodb.QueryResult)
+	if !ok {
+		return nil, resultTypeError("Query", ret, v)
+	}
+	return ret, err
+}
+
+func (m *Mock) Scan(table string, sopt *dynamodb.ScanOption) (*dynamodb.ScanResult, error) {
+	return m.ScanWithContext(context.Background(), table, sopt)
+}
+
+func (m *Mock) ScanWithContext(ctx context.Context, table string, sopt *dynamodb.ScanOption) (*dynamodb.ScanResult, error) {
+	v, err := m.call(ctx, "Scan", table, sopt)
+	if v == nil {
+		return nil, err
+	}
+	ret, ok := v.(*dynamodb.ScanResult)
+	if !ok {
+		return nil, resultTypeError("Scan", ret, v)
+	}
+	return ret, err
+}
+
+func (m *Mock) TagResource(resourceArn string, tags []dynamodb.Tag) error {
+	return m.TagResourceWithContext(context.Background(), resourceArn, tags)
+}
+
+func (m *Mock) TagResourceWithContext(ctx context.Context, resourceArn string, tags []dynamodb.Tag) error {
+	_, err := m.call(ctx, "TagResource", resourceArn, tags)
+	return err
+}
+
+func (m *Mock) UntagResource(resourceArn string, tagKeys []string) error {
+	return m.UntagResourceWithContext(context.Background(), resourceArn, tagKeys)
+}
+
+func (m *Mock) UntagResourceWithContext(ctx context.Context, resourceArn string, tagKeys []string) error {
+	_, err := m.call(ctx, "UntagResource", resourceArn, tagKeys)
+	return err
+}
+
+func (m *Mock) UpdateItem(table string, key map[string]dynamodb.AttributeValue, uopt *dynamodb.UpdateItemOption) (*dynamodb.UpdateItemResult, error) {
+	return m.UpdateItemWithContext(context.Background(), table, key, uopt)
+}
+
+func (m *Mock) UpdateItemWithContext(ctx context.Context, table string, key map[string]dynamodb.AttributeValue, uopt *dynamodb.UpdateItemOption) (*dynamodb.UpdateItemResult, error) {
+	v, err := m.call(ctx, "UpdateItem", table, key, uopt)
+	if v == nil {
+		return nil, err
+	}
+	ret, ok := v.(*dynamodb.UpdateItemResult)
+	if !ok {
+		return nil, resultTypeError("UpdateItem", ret, v)
+	}
+	return ret, err
+}
+
+func (m *Mock) UpdateTable(table string, uopt *dynamodb.UpdateTableOption) (*dynamodb.UpdateTableResult, error) {
+	return m.UpdateTableWithContext(context.Background(), table, uopt)
+}
+
+func (m *Mock) UpdateTableWithContext(ctx context.Context, table string, uopt *dynamodb.UpdateTableOption) (*dynamodb.UpdateTableResult, error) {
+	v, err := m.call(ctx, "UpdateTable", table, uopt)
+	if v == nil {
+		return nil, err
+	}
+	ret, ok := v.(*dynamodb.UpdateTableResult)
+	if !ok {
+		return nil, resultTypeError("UpdateTable", ret, v)
+	}
+	return ret, err
+}
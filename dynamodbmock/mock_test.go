@@ -0,0 +1,94 @@
+package dynamodbmock_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nabeken/goamz-dynamodb"
+	"github.com/nabeken/goamz-dynamodb/dynamodbmock"
+)
+
+// countItems is an example of code which depends on dynamodb.API.
+func countItems(api dynamodb.API, table string) (int64, error) {
+	var n int64
+	opt := &dynamodb.ScanOption{}
+	for {
+		ret, err := api.Scan(table, opt)
+		if err != nil {
+			return 0, err
+		}
+		n += ret.Count
+		if len(ret.LastEvaluatedKey) == 0 {
+			return n, nil
+		}
+		opt = &dynamodb.ScanOption{ExclusiveStartKey: ret.LastEvaluatedKey}
+	}
+}
+
+func TestMock(t *testing.T) {
+	m := dynamodbmock.New()
+	lastKey := map[string]dynamodb.AttributeValue{"ID": dynamodb.NewString("2")}
+	m.Return("Scan", &dynamodb.ScanResult{Count: 2, LastEvaluatedKey: lastKey}, nil).
+		Return("Scan", &dynamodb.ScanResult{Count: 1}, nil)
+
+	n, err := countItems(m, "TABLE")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+
+	calls := m.CallsOf("Scan")
+	if assert.Len(t, calls, 2) {
+		assert.Equal(t, []interface{}{"TABLE", &dynamodb.ScanOption{}}, calls[0].Args)
+		assert.Equal(t, []interface{}{"TABLE", &dynamodb.ScanOption{ExclusiveStartKey: lastKey}}, calls[1].Args)
+	}
+
+	// the last result is repeated
+	ret, err := m.ScanWithContext(context.Background(), "TABLE", nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(1), ret.Count)
+	}
+	assert.Len(t, m.Calls(), 3)
+}
+
+func TestMock_Errors(t *testing.T) {
+	m := dynamodbmock.New()
+
+	_, err := m.GetItem("TABLE", nil, nil)
+	assert.True(t, errors.Is(err, dynamodbmock.ErrNoResult))
+
+	m.Return("PutItem", nil, dynamodb.ErrConditionalCheckFailed)
+	ret, err := m.PutItem("TABLE", dynamodb.Item{}, nil)
+	assert.Nil(t, ret)
+	assert.True(t, errors.Is(err, dynamodb.ErrConditionalCheckFailed))
+
+	m.Return("TagResource", nil, nil)
+	assert.NoError(t, m.TagResource("ARN", nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, m.TagResourceWithContext(ctx, "ARN", nil))
+
+	assert.Len(t, m.Calls(), 4)
+	m.Reset()
+	assert.Empty(t, m.Calls())
+	assert.True(t, errors.Is(m.TagResource("ARN", nil), dynamodbmock.ErrNoResult))
+}
+
+func TestMock_ResultType(t *testing.T) {
+	m := dynamodbmock.New()
+	m.Return("GetItem", &dynamodb.PutItemResult{}, nil)
+	m.Return("Query", dynamodb.QueryResult{}, nil)
+
+	ret, err := m.GetItem("TABLE", nil, nil)
+	assert.Nil(t, ret)
+	if assert.True(t, errors.Is(err, dynamodbmock.ErrResultType), "%v", err) {
+		assert.Contains(t, err.Error(), "GetItem: expected *dynamodb.GetItemResult but got *dynamodb.PutItemResult")
+	}
+
+	_, err = m.Query("TABLE", nil, nil)
+	if assert.True(t, errors.Is(err, dynamodbmock.ErrResultType), "%v", err) {
+		assert.Contains(t, err.Error(), "expected *dynamodb.QueryResult but got dynamodb.QueryResult")
+	}
+}
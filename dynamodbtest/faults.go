@@ -0,0 +1,284 @@
+package dynamodbtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/nabeken/goamz-dynamodb"
+)
+
+// Fault is a failure which FaultInjector injects into a request.
+type Fault int
+
+const (
+	// FaultNone passes the request through. A Rule with FaultNone only adds Latency.
+	FaultNone Fault = iota
+
+	// FaultThrottling responds with 400 ThrottlingException without sending the request.
+	FaultThrottling
+
+	// FaultThroughputExceeded responds with 400 ProvisionedThroughputExceededException
+	// without sending the request.
+	FaultThroughputExceeded
+
+	// FaultInternalServerError responds with 500 InternalServerError without sending the request.
+	FaultInternalServerError
+
+	// FaultServiceUnavailable responds with 503 ServiceUnavailable without sending the request.
+	FaultServiceUnavailable
+
+	// FaultConnectionReset fails with ECONNRESET without sending the request.
+	FaultConnectionReset
+
+	// FaultTruncatedBody sends the request and cuts the response body in half
+	// as if the connection were lost after DynamoDB processed the request.
+	FaultTruncatedBody
+
+	// FaultCorruptedBody sends the request and changes a byte of the response body
+	// so that it does not match x-amz-crc32.
+	FaultCorruptedBody
+)
+
+func (f Fault) String() string {
+	switch f {
+	case FaultNone:
+		return "None"
+	case FaultThrottling:
+		return "Throttling"
+	case FaultThroughputExceeded:
+		return "ThroughputExceeded"
+	case FaultInternalServerError:
+		return "InternalServerError"
+	case FaultServiceUnavailable:
+		return "ServiceUnavailable"
+	case FaultConnectionReset:
+		return "ConnectionReset"
+	case FaultTruncatedBody:
+		return "TruncatedBody"
+	case FaultCorruptedBody:
+		return "CorruptedBody"
+	}
+	return "Fault(" + strconv.Itoa(int(f)) + ")"
+}
+
+// Rule tells FaultInjector when to inject a fault. A rule matches a request if
+// the attempt number is in Attempts, or otherwise with the probability of Rate.
+type Rule struct {
+	// Operation limits the rule to an operation such as "PutItem". Empty matches all.
+	Operation string
+
+	// Fault is injected when the rule matches.
+	Fault Fault
+
+	// Latency delays the request when the rule matches.
+	Latency time.Duration
+
+	// Attempts are the attempt numbers starting at 1 to inject on.
+	// Attempts are counted per operation.
+	Attempts []int
+
+	// Rate is the probability between 0 and 1 to inject on every attempt.
+	Rate float64
+}
+
+func (r *Rule) match(operation string, attempt int, rnd *rand.Rand) bool {
+	if r.Operation != "" && r.Operation != operation {
+		return false
+	}
+	for _, a := range r.Attempts {
+		if a == attempt {
+			return true
+		}
+	}
+	return r.Rate > 0 && rnd.Float64() < r.Rate
+}
+
+// Injection is a record of a request which FaultInjector handled.
+type Injection struct {
+	Operation string
+	Attempt   int
+	Fault     Fault
+	Latency   time.Duration
+}
+
+// FaultInjector is an http.RoundTripper which injects throttling errors, server errors,
+// connection resets, truncated and corrupted bodies and latency as Rules tell, so that
+// retries and idempotency can be tested deterministically. Set it to Transport of
+// Client.HTTPClient.
+//
+// The first matching rule with a Fault other than FaultNone decides the fault, and the
+// latencies of all matching rules are added up. Rates are drawn from a random source
+// seeded with Seed, so the same sequence of requests gets the same faults.
+type FaultInjector struct {
+	// Transport sends the requests. http.DefaultTransport is used if nil.
+	Transport http.RoundTripper
+
+	Rules []Rule
+
+	// Seed seeds the random source for Rate.
+	Seed int64
+
+	mu         sync.Mutex
+	rnd        *rand.Rand
+	attempts   map[string]int
+	injections []Injection
+}
+
+// NewFaultInjector returns a FaultInjector which sends requests with transport.
+func NewFaultInjector(transport http.RoundTripper, rules ...Rule) *FaultInjector {
+	return &FaultInjector{
+		Transport: transport,
+		Rules:     rules,
+	}
+}
+
+// Attempts returns the number of requests for the operation such as "PutItem".
+func (fi *FaultInjector) Attempts(operation string) int {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	return fi.attempts[operation]
+}
+
+// Injections returns the requests handled so far including the ones without faults.
+func (fi *FaultInjector) Injections() []Injection {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	return append([]Injection(nil), fi.injections...)
+}
+
+// Reset clears the attempt counts and the injections.
+func (fi *FaultInjector) Reset() {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	fi.attempts = nil
+	fi.injections = nil
+}
+
+// next counts the attempt and decides the fault and the latency for it.
+func (fi *FaultInjector) next(operation string) Injection {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	if fi.rnd == nil {
+		fi.rnd = rand.New(rand.NewSource(fi.Seed))
+	}
+	if fi.attempts == nil {
+		fi.attempts = map[string]int{}
+	}
+	fi.attempts[operation]++
+
+	in := Injection{Operation: operation, Attempt: fi.attempts[operation]}
+	for i := range fi.Rules {
+		r := &fi.Rules[i]
+		if !r.match(operation, in.Attempt, fi.rnd) {
+			continue
+		}
+		in.Latency += r.Latency
+		if in.Fault == FaultNone {
+			in.Fault = r.Fault
+		}
+	}
+	fi.injections = append(fi.injections, in)
+	return in
+}
+
+func (fi *FaultInjector) RoundTrip(req *http.Request) (*http.Response, error) {
+	in := fi.next(strings.TrimPrefix(req.Header.Get("X-Amz-Target"), targetPrefix))
+
+	if in.Latency > 0 {
+		t := time.NewTimer(in.Latency)
+		select {
+		case <-t.C:
+		case <-req.Context().Done():
+			t.Stop()
+			closeBody(req)
+			return nil, req.Context().Err()
+		}
+	}
+
+	switch in.Fault {
+	case FaultThrottling:
+		return faultResponse(req, http.StatusBadRequest, dynamodb.CodeThrottling, "Rate of requests exceeds the allowed throughput."), nil
+	case FaultThroughputExceeded:
+		return faultResponse(req, http.StatusBadRequest, dynamodb.CodeProvisionedThroughputExceeded,
+			"The level of configured provisioned throughput for the table was exceeded. Consider increasing your provisioning level with the UpdateTable API."), nil
+	case FaultInternalServerError:
+		return faultResponse(req, http.StatusInternalServerError, dynamodb.CodeInternalServerError, "Internal server error"), nil
+	case FaultServiceUnavailable:
+		return faultResponse(req, http.StatusServiceUnavailable, "ServiceUnavailable", "Service unavailable"), nil
+	case FaultConnectionReset:
+		closeBody(req)
+		return nil, &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+	}
+
+	transport := fi.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil || (in.Fault != FaultTruncatedBody && in.Fault != FaultCorruptedBody) {
+		return resp, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if in.Fault == FaultCorruptedBody {
+		if len(body) > 0 {
+			body[len(body)/2] ^= 0x01
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		return resp, nil
+	}
+	resp.Body = ioutil.NopCloser(io.MultiReader(
+		bytes.NewReader(body[:len(body)/2]),
+		errorReader{io.ErrUnexpectedEOF},
+	))
+	return resp, nil
+}
+
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
+
+// faultResponse builds an error response as DynamoDB returns.
+func faultResponse(req *http.Request, status int, code, message string) *http.Response {
+	closeBody(req)
+	body, _ := json.Marshal(newError(code, message))
+	header := http.Header{}
+	header.Set("Content-Type", "application/x-amz-json-1.0")
+	header.Set("X-Amz-Crc32", strconv.FormatUint(uint64(crc32.ChecksumIEEE(body)), 10))
+	return &http.Response{
+		Status:        strconv.Itoa(status) + " " + http.StatusText(status),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+type errorReader struct {
+	err error
+}
+
+func (r errorReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,128 @@
+package dynamodbtest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nabeken/goamz-dynamodb"
+	"github.com/nabeken/goamz-dynamodb/dynamodbtest"
+)
+
+var fastRetryer = dynamodb.DefaultRetryer{
+	NumMaxRetries:    3,
+	MinRetryDelay:    time.Millisecond,
+	MaxRetryDelay:    time.Millisecond,
+	MinThrottleDelay: time.Millisecond,
+	MaxThrottleDelay: time.Millisecond,
+}
+
+func newFaultInjector(t *testing.T, rules ...dynamodbtest.Rule) (*dynamodbtest.FaultInjector, *dynamodb.Client) {
+	srv, c := newServer(t)
+	fi := dynamodbtest.NewFaultInjector(srv.Client().Transport, rules...)
+	c.HTTPClient.Transport = fi
+	c.Retryer = fastRetryer
+	return fi, c
+}
+
+func TestFaultInjector_Retry(t *testing.T) {
+	for _, fault := range []dynamodbtest.Fault{
+		dynamodbtest.FaultThrottling,
+		dynamodbtest.FaultThroughputExceeded,
+		dynamodbtest.FaultInternalServerError,
+		dynamodbtest.FaultServiceUnavailable,
+		dynamodbtest.FaultConnectionReset,
+		dynamodbtest.FaultTruncatedBody,
+		dynamodbtest.FaultCorruptedBody,
+	} {
+		fi, c := newFaultInjector(t, dynamodbtest.Rule{Operation: "GetItem", Fault: fault, Attempts: []int{1, 2}})
+
+		_, err := c.GetItem("Messages", key("alice", 1), nil)
+		assert.NoError(t, err, fault.String())
+		assert.Equal(t, 3, fi.Attempts("GetItem"), fault.String())
+	}
+}
+
+func TestFaultInjector_Error(t *testing.T) {
+	fi, c := newFaultInjector(t,
+		dynamodbtest.Rule{Operation: "GetItem", Fault: dynamodbtest.FaultThrottling, Rate: 1},
+		dynamodbtest.Rule{Operation: "Query", Fault: dynamodbtest.FaultServiceUnavailable, Rate: 1},
+	)
+	c.Retryer = dynamodb.NoOpRetryer{}
+
+	_, err := c.GetItem("Messages", key("alice", 1), nil)
+	assert.True(t, errors.Is(err, dynamodb.ErrThrottling), "%v", err)
+
+	_, err = c.Query("Messages", &dynamodb.KeyConditions{
+		"UserId": {ComparisonOperator: dynamodb.CmpOpEQ, AttributeValueList: []dynamodb.AttributeValue{dynamodb.NewString("alice")}},
+	}, nil)
+	assert.Equal(t, "ServiceUnavailable", dynamodb.ErrorCode(err))
+
+	// the other operations pass through
+	_, err = c.PutItem("Messages", message("alice", 1), nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []dynamodbtest.Injection{
+		{Operation: "GetItem", Attempt: 1, Fault: dynamodbtest.FaultThrottling},
+		{Operation: "Query", Attempt: 1, Fault: dynamodbtest.FaultServiceUnavailable},
+		{Operation: "PutItem", Attempt: 1},
+	}, fi.Injections())
+
+	fi.Reset()
+	assert.Equal(t, 0, fi.Attempts("GetItem"))
+	assert.Empty(t, fi.Injections())
+}
+
+func TestFaultInjector_TruncatedBody(t *testing.T) {
+	fi, c := newFaultInjector(t, dynamodbtest.Rule{Operation: "PutItem", Fault: dynamodbtest.FaultTruncatedBody, Attempts: []int{1}})
+
+	// the first attempt is applied although its response is lost,
+	// so the retry of a conditional put fails
+	_, err := c.PutItem("Messages", message("alice", 1), &dynamodb.PutItemOption{
+		Expected: dynamodb.ExpectedAttributeValue{"UserId": {ComparisonOperator: dynamodb.CmpOpNull}},
+	})
+	assert.True(t, errors.Is(err, dynamodb.ErrConditionalCheckFailed), "%v", err)
+	assert.Equal(t, 2, fi.Attempts("PutItem"))
+}
+
+func TestFaultInjector_Rate(t *testing.T) {
+	faults := func() []dynamodbtest.Fault {
+		fi, c := newFaultInjector(t, dynamodbtest.Rule{Fault: dynamodbtest.FaultInternalServerError, Rate: 0.5})
+		fi.Seed = 42
+		c.Retryer = dynamodb.NoOpRetryer{}
+		for i := 0; i < 20; i++ {
+			c.GetItem("Messages", key("alice", 1), nil)
+		}
+		var ret []dynamodbtest.Fault
+		for _, in := range fi.Injections() {
+			ret = append(ret, in.Fault)
+		}
+		return ret
+	}
+
+	first := faults()
+	assert.Contains(t, first, dynamodbtest.FaultInternalServerError)
+	assert.Contains(t, first, dynamodbtest.FaultNone)
+	assert.Equal(t, first, faults())
+}
+
+func TestFaultInjector_Latency(t *testing.T) {
+	_, c := newFaultInjector(t,
+		dynamodbtest.Rule{Operation: "GetItem", Latency: 20 * time.Millisecond, Attempts: []int{1}},
+		dynamodbtest.Rule{Operation: "Scan", Latency: time.Minute, Rate: 1},
+	)
+
+	start := time.Now()
+	_, err := c.GetItem("Messages", key("alice", 1), nil)
+	assert.NoError(t, err)
+	assert.True(t, time.Since(start) >= 20*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	c.Retryer = dynamodb.NoOpRetryer{}
+	_, err = c.ScanWithContext(ctx, "Messages", nil)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "%v", err)
+}
@@ -0,0 +1,864 @@
+package dynamodbtest
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"github.com/nabeken/goamz-dynamodb"
+)
+
+// Limits enforced by DynamoDB.
+const (
+	maxItemSize       = 400 * 1024
+	maxBatchGetItems  = 100
+	maxBatchWriteItem = 25
+)
+
+var errConditionalCheckFailed = newError(dynamodb.CodeConditionalCheckFailed, "The conditional request failed")
+
+// keyString encodes the values of names in item into a string which identifies the item.
+// Values must be normalized.
+func keyString(item dynamodb.Item, names ...string) string {
+	var b strings.Builder
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		av := item[name]
+		b.WriteString(string(av.Type))
+		b.WriteByte(':')
+		if len(av.Data) > 0 {
+			b.WriteString(string(av.Data[0]))
+		}
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+// normalize validates av and formats numbers and binaries in the canonical form
+// so that equal values are encoded into the same keyString. Maps and lists are normalized recursively.
+func normalize(av dynamodb.AttributeValue) (dynamodb.AttributeValue, error) {
+	switch av.Type {
+	case dynamodb.TypeMap:
+		ret := dynamodb.AttributeValue{Type: av.Type, Map: make(map[string]dynamodb.AttributeValue, len(av.Map))}
+		for name, v := range av.Map {
+			nv, err := normalize(v)
+			if err != nil {
+				return ret, err
+			}
+			ret.Map[name] = nv
+		}
+		return ret, nil
+	case dynamodb.TypeList:
+		ret := dynamodb.AttributeValue{Type: av.Type, List: make([]dynamodb.AttributeValue, len(av.List))}
+		for i, v := range av.List {
+			nv, err := normalize(v)
+			if err != nil {
+				return ret, err
+			}
+			ret.List[i] = nv
+		}
+		return ret, nil
+	}
+
+	ret := dynamodb.AttributeValue{Type: av.Type, Data: make([]dynamodb.AttributeData, 0, len(av.Data))}
+	switch av.Type {
+	case dynamodb.TypeString, dynamodb.TypeNumber, dynamodb.TypeBinary, dynamodb.TypeBool, dynamodb.TypeNull:
+		if len(av.Data) != 1 {
+			return ret, validationError("Supplied AttributeValue has more than one datatypes set, must contain exactly one of the supported datatypes")
+		}
+	case dynamodb.TypeStringSet, dynamodb.TypeNumberSet, dynamodb.TypeBinarySet:
+		if len(av.Data) == 0 {
+			return ret, validationError("One or more parameter values were invalid: An %s set may not be empty", av.Type)
+		}
+	default:
+		return ret, validationError("Supplied AttributeValue is empty, must contain exactly one of the supported datatypes")
+	}
+
+	seen := map[dynamodb.AttributeData]bool{}
+	for _, d := range av.Data {
+		switch av.Type {
+		case dynamodb.TypeNumber, dynamodb.TypeNumberSet:
+			r, ok := dynamodb.ParseNumber(d)
+			if !ok {
+				return ret, validationError("The parameter cannot be converted to a numeric value: %s", d)
+			}
+			d = dynamodb.AttributeData(formatNumber(r))
+		case dynamodb.TypeBinary, dynamodb.TypeBinarySet:
+			b, err := base64.StdEncoding.DecodeString(string(d))
+			if err != nil {
+				return ret, newError(CodeSerialization, "Base64 encoded length is expected a multiple of 4 bytes but found: %d", len(d))
+			}
+			d = dynamodb.AttributeData(base64.StdEncoding.EncodeToString(b))
+		case dynamodb.TypeBool:
+			if d != "true" && d != "false" {
+				return ret, newError(CodeSerialization, "Unexpected value for BOOL: %s", d)
+			}
+		case dynamodb.TypeNull:
+			if d != "true" {
+				return ret, validationError("One or more parameter values were invalid: Null attribute value types must have the value of true")
+			}
+		}
+		if seen[d] {
+			return ret, validationError("One or more parameter values were invalid: Input collection %v contains duplicates.", av.Data)
+		}
+		seen[d] = true
+		ret.Data = append(ret.Data, d)
+	}
+	return ret, nil
+}
+
+func normalizeItem(item dynamodb.Item) (dynamodb.Item, error) {
+	ret := dynamodb.Item{}
+	for name, av := range item {
+		nav, err := normalize(av)
+		if err != nil {
+			return nil, err
+		}
+		ret[name] = nav
+	}
+	return ret, nil
+}
+
+func normalizeConditions(conds map[string]dynamodb.Condition) (map[string]dynamodb.Condition, error) {
+	ret := map[string]dynamodb.Condition{}
+	for name, cond := range conds {
+		args := make([]dynamodb.AttributeValue, len(cond.AttributeValueList))
+		for i, av := range cond.AttributeValueList {
+			var err error
+			if args[i], err = normalize(av); err != nil {
+				return nil, err
+			}
+		}
+		ret[name] = dynamodb.Condition{ComparisonOperator: cond.ComparisonOperator, AttributeValueList: args}
+	}
+	// Evaluate validates every condition even against an empty item
+	if _, err := dynamodb.Evaluate(dynamodb.Item{}, ret, ""); err != nil {
+		return nil, conditionError(err)
+	}
+	return ret, nil
+}
+
+// conditionError converts an error from dynamodb.Evaluate into ValidationException.
+func conditionError(err error) error {
+	return validationError("One or more parameter values were invalid: " + strings.TrimPrefix(err.Error(), "dynamodb: "))
+}
+
+// key validates the primary key in item and returns its keyString and the key attributes.
+// If exact is true, item must not have other attributes.
+func (t *table) key(item dynamodb.Item, exact bool) (string, dynamodb.Item, error) {
+	key := dynamodb.Item{}
+	for _, name := range []string{t.hash, t.rng} {
+		if name == "" {
+			continue
+		}
+		av, ok := item[name]
+		if !ok {
+			if exact {
+				return "", nil, validationError("The provided key element does not match the schema")
+			}
+			return "", nil, validationError("One or more parameter values were invalid: Missing the key %s in the item", name)
+		}
+		if av.Type != t.attrType(name) {
+			if exact {
+				return "", nil, validationError("The provided key element does not match the schema")
+			}
+			return "", nil, validationError("One or more parameter values were invalid: Type mismatch for key %s expected: %s actual: %s", name, t.attrType(name), av.Type)
+		}
+		if av.Data[0] == "" {
+			return "", nil, validationError("One or more parameter values are not valid. The AttributeValue for a key attribute cannot contain an empty string value. Key: %s", name)
+		}
+		key[name] = av
+	}
+	if exact && len(item) != len(key) {
+		return "", nil, validationError("The provided key element does not match the schema")
+	}
+	return keyString(key, t.hash, t.rng), key, nil
+}
+
+// validateIndexKeys checks the types of the index key attributes in item.
+func (t *table) validateIndexKeys(item dynamodb.Item) error {
+	for _, idx := range t.indexes {
+		for _, name := range []string{idx.hash, idx.rng} {
+			av, ok := item[name]
+			if name == "" || !ok {
+				continue
+			}
+			if typ := t.attrType(name); av.Type != typ {
+				return validationError("One or more parameter values were invalid: Type mismatch for Index Key %s Expected: %s Actual: %s IndexName: %s", name, typ, av.Type, idx.name)
+			}
+			if av.Data[0] == "" {
+				return validationError("One or more parameter values are not valid. A value specified for a secondary index key is not supported. The AttributeValue for a key attribute cannot contain an empty string value. IndexName: %s, IndexKey: %s", idx.name, name)
+			}
+		}
+	}
+	return nil
+}
+
+// store validates item and puts it into the table.
+func (t *table) store(k string, item dynamodb.Item) error {
+	if err := t.validateIndexKeys(item); err != nil {
+		return err
+	}
+	if itemSize(item) > maxItemSize {
+		return validationError("Item size has exceeded the maximum allowed size")
+	}
+	t.items[k] = item
+	return nil
+}
+
+// inIndex reports whether item has the key attributes of idx.
+func inIndex(idx *index, item dynamodb.Item) bool {
+	if _, ok := item[idx.hash]; !ok {
+		return false
+	}
+	if idx.rng == "" {
+		return true
+	}
+	_, ok := item[idx.rng]
+	return ok
+}
+
+// project returns the attributes of item which keep reports true for. keep == nil keeps all.
+func project(item dynamodb.Item, keep func(name string) bool) dynamodb.Item {
+	if item == nil {
+		return nil
+	}
+	ret := dynamodb.Item{}
+	for name, av := range item {
+		if keep == nil || keep(name) {
+			ret[name] = av
+		}
+	}
+	return ret
+}
+
+// attributeFilter returns a filter for project which keeps names. No names keeps all.
+func attributeFilter(names []string) func(string) bool {
+	if len(names) == 0 {
+		return nil
+	}
+	set := map[string]bool{}
+	for _, name := range names {
+		set[name] = true
+	}
+	return func(name string) bool { return set[name] }
+}
+
+func copyItem(item dynamodb.Item) dynamodb.Item {
+	ret := dynamodb.Item{}
+	for name, av := range item {
+		ret[name] = copyValue(av)
+	}
+	return ret
+}
+
+func copyValue(av dynamodb.AttributeValue) dynamodb.AttributeValue {
+	ret := dynamodb.AttributeValue{Type: av.Type, Data: append([]dynamodb.AttributeData(nil), av.Data...)}
+	if av.Map != nil {
+		ret.Map = make(map[string]dynamodb.AttributeValue, len(av.Map))
+		for name, v := range av.Map {
+			ret.Map[name] = copyValue(v)
+		}
+	}
+	if av.List != nil {
+		ret.List = make([]dynamodb.AttributeValue, len(av.List))
+		for i, v := range av.List {
+			ret.List[i] = copyValue(v)
+		}
+	}
+	return ret
+}
+
+// itemSize approximates the size of item as DynamoDB calculates it.
+func itemSize(item dynamodb.Item) int64 {
+	var size int64
+	for name, av := range item {
+		size += int64(len(name)) + valueSize(av)
+	}
+	return size
+}
+
+// valueSize approximates the size of av. A map or a list takes 3 bytes and 1 byte per element.
+func valueSize(av dynamodb.AttributeValue) int64 {
+	var size int64
+	switch av.Type {
+	case dynamodb.TypeMap:
+		size += 3
+		for name, v := range av.Map {
+			size += 1 + int64(len(name)) + valueSize(v)
+		}
+	case dynamodb.TypeList:
+		size += 3
+		for _, v := range av.List {
+			size += 1 + valueSize(v)
+		}
+	}
+	for _, d := range av.Data {
+		switch av.Type {
+		case dynamodb.TypeBinary, dynamodb.TypeBinarySet:
+			b, _ := base64.StdEncoding.DecodeString(string(d))
+			size += int64(len(b))
+		case dynamodb.TypeBool, dynamodb.TypeNull:
+			size++
+		default:
+			size += int64(len(d))
+		}
+	}
+	return size
+}
+
+// capacityUnits returns the units to read or write size bytes in unit bytes.
+func capacityUnits(size, unit int64) float64 {
+	n := (size + unit - 1) / unit
+	if n < 1 {
+		n = 1
+	}
+	return float64(n)
+}
+
+func readUnits(size int64, consistent bool) float64 {
+	units := capacityUnits(size, 4096)
+	if !consistent {
+		units /= 2
+	}
+	return units
+}
+
+func writeUnits(items ...dynamodb.Item) float64 {
+	var size int64
+	for _, item := range items {
+		if s := itemSize(item); s > size {
+			size = s
+		}
+	}
+	return capacityUnits(size, 1024)
+}
+
+func consumedCapacity(t *table, mode dynamodb.ReturnConsumedCapacity, units float64) *dynamodb.ConsumedCapacity {
+	switch mode {
+	case dynamodb.ConsumedCapTotal:
+		return &dynamodb.ConsumedCapacity{TableName: t.name, CapacityUnits: units}
+	case dynamodb.ConsumedCapIndexes:
+		return &dynamodb.ConsumedCapacity{TableName: t.name, CapacityUnits: units, Table: dynamodb.Capacity{CapacityUnits: units}}
+	}
+	return nil
+}
+
+func setCapacity(ret map[string]interface{}, t *table, mode dynamodb.ReturnConsumedCapacity, units float64) {
+	if cc := consumedCapacity(t, mode, units); cc != nil {
+		ret["ConsumedCapacity"] = cc
+	}
+}
+
+// expectedAttributeValue accepts both of the Condition form and the legacy Value and Exists form.
+type expectedAttributeValue struct {
+	ComparisonOperator dynamodb.ComparisonOperator
+	AttributeValueList []dynamodb.AttributeValue
+	Value              *dynamodb.AttributeValue
+	Exists             *bool
+}
+
+// checkExpected returns ConditionalCheckFailedException unless item satisfies expected.
+func checkExpected(item dynamodb.Item, expected map[string]expectedAttributeValue, op dynamodb.ConditionalOperator) error {
+	conds := map[string]dynamodb.Condition{}
+	for name, e := range expected {
+		switch {
+		case e.ComparisonOperator != "":
+			if e.Value != nil || e.Exists != nil {
+				return validationError("One or more parameter values were invalid: Value or Exists cannot be used with ComparisonOperator for Attribute: %s", name)
+			}
+			conds[name] = dynamodb.Condition{ComparisonOperator: e.ComparisonOperator, AttributeValueList: e.AttributeValueList}
+		case e.Exists != nil && !*e.Exists:
+			if e.Value != nil {
+				return validationError("One or more parameter values were invalid: Value cannot be used when Exists is false for Attribute: %s", name)
+			}
+			conds[name] = dynamodb.Condition{ComparisonOperator: dynamodb.CmpOpNull}
+		case e.Value != nil:
+			conds[name] = dynamodb.Condition{ComparisonOperator: dynamodb.CmpOpEQ, AttributeValueList: []dynamodb.AttributeValue{*e.Value}}
+		default:
+			return validationError("One or more parameter values were invalid: Exists is set to TRUE for attribute (%s), Value must also be set", name)
+		}
+	}
+	conds, err := normalizeConditions(conds)
+	if err != nil {
+		return err
+	}
+	ok, err := dynamodb.Evaluate(item, conds, op)
+	if err != nil {
+		return conditionError(err)
+	}
+	if !ok {
+		return errConditionalCheckFailed
+	}
+	return nil
+}
+
+type getItemInput struct {
+	TableName              string
+	Key                    dynamodb.Item
+	AttributesToGet        []string
+	ConsistentRead         bool
+	ReturnConsumedCapacity dynamodb.ReturnConsumedCapacity
+}
+
+func (h *Handler) getItem(body []byte) (interface{}, error) {
+	var in getItemInput
+	if err := decode(body, &in); err != nil {
+		return nil, err
+	}
+	t, err := h.lookup(in.TableName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := normalizeItem(in.Key)
+	if err != nil {
+		return nil, err
+	}
+	k, _, err := t.key(key, true)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := map[string]interface{}{}
+	item, ok := t.items[k]
+	if ok {
+		ret["Item"] = project(item, attributeFilter(in.AttributesToGet))
+	}
+	setCapacity(ret, t, in.ReturnConsumedCapacity, readUnits(itemSize(item), in.ConsistentRead))
+	return ret, nil
+}
+
+type writeInput struct {
+	TableName              string
+	Expected               map[string]expectedAttributeValue
+	ConditionalOperator    dynamodb.ConditionalOperator
+	ReturnValues           dynamodb.ReturnValues
+	ReturnConsumedCapacity dynamodb.ReturnConsumedCapacity
+}
+
+func (in *writeInput) validateReturnValues(allowed ...dynamodb.ReturnValues) error {
+	if in.ReturnValues == "" || in.ReturnValues == dynamodb.ReturnValuesNone {
+		return nil
+	}
+	for _, rv := range allowed {
+		if in.ReturnValues == rv {
+			return nil
+		}
+	}
+	return validationError("Return values set to invalid value")
+}
+
+type putItemInput struct {
+	writeInput
+	Item dynamodb.Item
+}
+
+func (h *Handler) putItem(body []byte) (interface{}, error) {
+	var in putItemInput
+	if err := decode(body, &in); err != nil {
+		return nil, err
+	}
+	t, err := h.lookup(in.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if err := in.validateReturnValues(dynamodb.ReturnValuesAllOld); err != nil {
+		return nil, err
+	}
+	item, err := normalizeItem(in.Item)
+	if err != nil {
+		return nil, err
+	}
+	k, _, err := t.key(item, false)
+	if err != nil {
+		return nil, err
+	}
+	old := t.items[k]
+	if err := checkExpected(old, in.Expected, in.ConditionalOperator); err != nil {
+		return nil, err
+	}
+	if err := t.store(k, item); err != nil {
+		return nil, err
+	}
+
+	ret := map[string]interface{}{}
+	if in.ReturnValues == dynamodb.ReturnValuesAllOld && old != nil {
+		ret["Attributes"] = old
+	}
+	setCapacity(ret, t, in.ReturnConsumedCapacity, writeUnits(old, item))
+	return ret, nil
+}
+
+type deleteItemInput struct {
+	writeInput
+	Key dynamodb.Item
+}
+
+func (h *Handler) deleteItem(body []byte) (interface{}, error) {
+	var in deleteItemInput
+	if err := decode(body, &in); err != nil {
+		return nil, err
+	}
+	t, err := h.lookup(in.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if err := in.validateReturnValues(dynamodb.ReturnValuesAllOld); err != nil {
+		return nil, err
+	}
+	key, err := normalizeItem(in.Key)
+	if err != nil {
+		return nil, err
+	}
+	k, _, err := t.key(key, true)
+	if err != nil {
+		return nil, err
+	}
+	old := t.items[k]
+	if err := checkExpected(old, in.Expected, in.ConditionalOperator); err != nil {
+		return nil, err
+	}
+	delete(t.items, k)
+
+	ret := map[string]interface{}{}
+	if in.ReturnValues == dynamodb.ReturnValuesAllOld && old != nil {
+		ret["Attributes"] = old
+	}
+	setCapacity(ret, t, in.ReturnConsumedCapacity, writeUnits(old))
+	return ret, nil
+}
+
+type attributeUpdate struct {
+	Action dynamodb.UpdateAction
+	Value  *dynamodb.AttributeValue
+}
+
+type updateItemInput struct {
+	writeInput
+	Key              dynamodb.Item
+	AttributeUpdates map[string]attributeUpdate
+}
+
+func (h *Handler) updateItem(body []byte) (interface{}, error) {
+	var in updateItemInput
+	if err := decode(body, &in); err != nil {
+		return nil, err
+	}
+	t, err := h.lookup(in.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if err := in.validateReturnValues(dynamodb.ReturnValuesAllOld, dynamodb.ReturnValuesUpdatedOld,
+		dynamodb.ReturnValuesAllNew, dynamodb.ReturnValuesUpdatedNew); err != nil {
+		return nil, err
+	}
+	key, err := normalizeItem(in.Key)
+	if err != nil {
+		return nil, err
+	}
+	k, key, err := t.key(key, true)
+	if err != nil {
+		return nil, err
+	}
+	old := t.items[k]
+	if err := checkExpected(old, in.Expected, in.ConditionalOperator); err != nil {
+		return nil, err
+	}
+
+	item := copyItem(old)
+	for name, av := range key {
+		item[name] = av
+	}
+	updated := []string{}
+	for name, u := range in.AttributeUpdates {
+		if t.isKey(name) {
+			return nil, validationError("One or more parameter values were invalid: Cannot update attribute %s. This attribute is part of the key", name)
+		}
+		if err := applyUpdate(item, name, u); err != nil {
+			return nil, err
+		}
+		updated = append(updated, name)
+	}
+	if err := t.store(k, item); err != nil {
+		return nil, err
+	}
+
+	var attrs dynamodb.Item
+	switch in.ReturnValues {
+	case dynamodb.ReturnValuesAllOld:
+		attrs = old
+	case dynamodb.ReturnValuesUpdatedOld:
+		if len(updated) > 0 {
+			attrs = project(old, attributeFilter(updated))
+		}
+	case dynamodb.ReturnValuesAllNew:
+		attrs = item
+	case dynamodb.ReturnValuesUpdatedNew:
+		if len(updated) > 0 {
+			attrs = project(item, attributeFilter(updated))
+		}
+	}
+	ret := map[string]interface{}{}
+	if len(attrs) > 0 {
+		ret["Attributes"] = attrs
+	}
+	setCapacity(ret, t, in.ReturnConsumedCapacity, writeUnits(old, item))
+	return ret, nil
+}
+
+// applyUpdate applies u to the attribute name in item.
+func applyUpdate(item dynamodb.Item, name string, u attributeUpdate) error {
+	var value dynamodb.AttributeValue
+	if u.Value != nil {
+		var err error
+		if value, err = normalize(*u.Value); err != nil {
+			return err
+		}
+	}
+	current, exists := item[name]
+
+	switch u.Action {
+	case "", dynamodb.ActionPut:
+		if u.Value == nil {
+			return validationError("One or more parameter values were invalid: Only DELETE action is allowed when no attribute value is specified")
+		}
+		item[name] = value
+	case dynamodb.ActionDelete:
+		if u.Value == nil {
+			delete(item, name)
+			return nil
+		}
+		if !value.Type.IsSet() {
+			return validationError("One or more parameter values were invalid: DELETE action with value is not supported for the type %s", value.Type)
+		}
+		if !exists {
+			return nil
+		}
+		if current.Type != value.Type {
+			return validationError("Type mismatch for DELETE; operator type: %s, existing type: %s", value.Type, current.Type)
+		}
+		remain := dynamodb.AttributeValue{Type: current.Type}
+		for _, d := range current.Data {
+			if !setContains(value, d) {
+				remain.Data = append(remain.Data, d)
+			}
+		}
+		if len(remain.Data) == 0 {
+			delete(item, name)
+		} else {
+			item[name] = remain
+		}
+	case dynamodb.ActionAdd:
+		if u.Value == nil {
+			return validationError("One or more parameter values were invalid: Only DELETE action is allowed when no attribute value is specified")
+		}
+		if value.Type != dynamodb.TypeNumber && value.Type != dynamodb.TypeList && !value.Type.IsSet() {
+			return validationError("One or more parameter values were invalid: ADD action is not supported for the type %s", value.Type)
+		}
+		if !exists {
+			item[name] = value
+			return nil
+		}
+		if current.Type != value.Type {
+			return validationError("Type mismatch for attribute to update")
+		}
+		if value.Type == dynamodb.TypeList {
+			// a list is appended to
+			list := append(append([]dynamodb.AttributeValue(nil), current.List...), value.List...)
+			item[name] = dynamodb.NewList(list...)
+			return nil
+		}
+		if value.Type == dynamodb.TypeNumber {
+			a, _ := dynamodb.ParseNumber(current.Data[0])
+			b, _ := dynamodb.ParseNumber(value.Data[0])
+			item[name] = dynamodb.AttributeValue{
+				Type: dynamodb.TypeNumber,
+				Data: []dynamodb.AttributeData{dynamodb.AttributeData(formatNumber(a.Add(a, b)))},
+			}
+			return nil
+		}
+		union := dynamodb.AttributeValue{Type: current.Type, Data: append([]dynamodb.AttributeData(nil), current.Data...)}
+		for _, d := range value.Data {
+			if !setContains(union, d) {
+				union.Data = append(union.Data, d)
+			}
+		}
+		item[name] = union
+	default:
+		return validationError("1 validation error detected: Value '%s' at 'attributeUpdates.%s.member.action' failed to satisfy constraint: Member must satisfy enum value set: [ADD, PUT, DELETE]", u.Action, name)
+	}
+	return nil
+}
+
+type batchGetItemInput struct {
+	RequestItems           map[string]dynamodb.KeysAndAttributes
+	ReturnConsumedCapacity dynamodb.ReturnConsumedCapacity
+}
+
+func (h *Handler) batchGetItem(body []byte) (interface{}, error) {
+	var in batchGetItemInput
+	if err := decode(body, &in); err != nil {
+		return nil, err
+	}
+	if len(in.RequestItems) == 0 {
+		return nil, validationError("1 validation error detected: Value null at 'requestItems' failed to satisfy constraint: Member must have length greater than or equal to 1")
+	}
+
+	var total int
+	for _, ka := range in.RequestItems {
+		total += len(ka.Keys)
+	}
+	if total > maxBatchGetItems {
+		return nil, validationError("Too many items requested for the BatchGetItem call")
+	}
+
+	responses := map[string][]dynamodb.Item{}
+	ccs := []*dynamodb.ConsumedCapacity{}
+	for name, ka := range in.RequestItems {
+		t, err := h.lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		if len(ka.Keys) == 0 {
+			return nil, validationError("1 validation error detected: Value '[]' at 'requestItems.%s.member.keys' failed to satisfy constraint: Member must have length greater than or equal to 1", name)
+		}
+		items := []dynamodb.Item{}
+		seen := map[string]bool{}
+		var units float64
+		for _, key := range ka.Keys {
+			key, err := normalizeItem(key)
+			if err != nil {
+				return nil, err
+			}
+			k, _, err := t.key(key, true)
+			if err != nil {
+				return nil, err
+			}
+			if seen[k] {
+				return nil, validationError("Provided list of item keys contains duplicates")
+			}
+			seen[k] = true
+			if item, ok := t.items[k]; ok {
+				items = append(items, project(item, attributeFilter(ka.AttributesToGet)))
+				units += readUnits(itemSize(item), ka.ConsistentRead)
+			}
+		}
+		responses[name] = items
+		if cc := consumedCapacity(t, in.ReturnConsumedCapacity, units); cc != nil {
+			ccs = append(ccs, cc)
+		}
+	}
+
+	ret := map[string]interface{}{
+		"Responses":       responses,
+		"UnprocessedKeys": map[string]interface{}{},
+	}
+	if len(ccs) > 0 {
+		ret["ConsumedCapacity"] = ccs
+	}
+	return ret, nil
+}
+
+type writeRequest struct {
+	PutRequest *struct {
+		Item dynamodb.Item
+	}
+	DeleteRequest *struct {
+		Key dynamodb.Item
+	}
+}
+
+type batchWriteItemInput struct {
+	RequestItems           map[string][]writeRequest
+	ReturnConsumedCapacity dynamodb.ReturnConsumedCapacity
+}
+
+func (h *Handler) batchWriteItem(body []byte) (interface{}, error) {
+	var in batchWriteItemInput
+	if err := decode(body, &in); err != nil {
+		return nil, err
+	}
+
+	var total int
+	for _, wrs := range in.RequestItems {
+		total += len(wrs)
+	}
+	if total == 0 || total > maxBatchWriteItem {
+		return nil, validationError("1 validation error detected: Value at 'requestItems' failed to satisfy constraint: Map value must satisfy constraint: [Member must have length less than or equal to %d, Member must have length greater than or equal to 1]", maxBatchWriteItem)
+	}
+
+	// every request is validated before any is applied
+	type write struct {
+		t    *table
+		k    string
+		item dynamodb.Item // nil to delete
+	}
+	var writes []write
+	for name, wrs := range in.RequestItems {
+		t, err := h.lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		seen := map[string]bool{}
+		for _, wr := range wrs {
+			var w write
+			switch {
+			case wr.PutRequest != nil && wr.DeleteRequest == nil:
+				item, err := normalizeItem(wr.PutRequest.Item)
+				if err != nil {
+					return nil, err
+				}
+				if w.k, _, err = t.key(item, false); err != nil {
+					return nil, err
+				}
+				if err := t.validateIndexKeys(item); err != nil {
+					return nil, err
+				}
+				if itemSize(item) > maxItemSize {
+					return nil, validationError("Item size has exceeded the maximum allowed size")
+				}
+				w.item = item
+			case wr.DeleteRequest != nil && wr.PutRequest == nil:
+				key, err := normalizeItem(wr.DeleteRequest.Key)
+				if err != nil {
+					return nil, err
+				}
+				if w.k, _, err = t.key(key, true); err != nil {
+					return nil, err
+				}
+			default:
+				return nil, validationError("One or more parameter values were invalid: A WriteRequest must contain exactly one of PutRequest or DeleteRequest")
+			}
+			if seen[w.k] {
+				return nil, validationError("Provided list of item keys contains duplicates")
+			}
+			seen[w.k] = true
+			w.t = t
+			writes = append(writes, w)
+		}
+	}
+
+	units := map[*table]float64{}
+	for _, w := range writes {
+		old := w.t.items[w.k]
+		if w.item == nil {
+			delete(w.t.items, w.k)
+		} else {
+			w.t.items[w.k] = w.item
+		}
+		units[w.t] += writeUnits(old, w.item)
+	}
+
+	ret := map[string]interface{}{"UnprocessedItems": map[string]interface{}{}}
+	ccs := []*dynamodb.ConsumedCapacity{}
+	for t, u := range units {
+		if cc := consumedCapacity(t, in.ReturnConsumedCapacity, u); cc != nil {
+			ccs = append(ccs, cc)
+		}
+	}
+	if len(ccs) > 0 {
+		ret["ConsumedCapacity"] = ccs
+	}
+	return ret, nil
+}
@@ -0,0 +1,327 @@
+package dynamodbtest
+
+import (
+	"hash/fnv"
+	"sort"
+
+	"github.com/nabeken/goamz-dynamodb"
+)
+
+// maxPageSize is the maximum size of items which Query and Scan read at once.
+const maxPageSize = 1024 * 1024
+
+// source is a table or an index which Query and Scan read items from.
+type source struct {
+	t         *table
+	idx       *index
+	hash, rng string
+}
+
+func (h *Handler) source(tableName, indexName string, consistent bool) (*source, error) {
+	t, err := h.lookup(tableName)
+	if err != nil {
+		return nil, err
+	}
+	if indexName == "" {
+		return &source{t: t, hash: t.hash, rng: t.rng}, nil
+	}
+	idx, err := t.index(indexName)
+	if err != nil {
+		return nil, err
+	}
+	if idx.global && consistent {
+		return nil, validationError("Consistent reads are not supported on global secondary indexes")
+	}
+	return &source{t: t, idx: idx, hash: idx.hash, rng: idx.rng}, nil
+}
+
+// less orders items by the hash key, the range key and then the primary key of the table.
+func (s *source) less(a, b dynamodb.Item) bool {
+	if ha, hb := keyString(a, s.hash), keyString(b, s.hash); ha != hb {
+		return ha < hb
+	}
+	if s.rng != "" {
+		if c, _ := dynamodb.Compare(a[s.rng], b[s.rng]); c != 0 {
+			return c < 0
+		}
+	}
+	return keyString(a, s.t.hash, s.t.rng) < keyString(b, s.t.hash, s.t.rng)
+}
+
+// items returns the items in the source which match keep in order.
+func (s *source) items(keep func(dynamodb.Item) (bool, error)) ([]dynamodb.Item, error) {
+	var items []dynamodb.Item
+	for _, item := range s.t.items {
+		if s.idx != nil && !inIndex(s.idx, item) {
+			continue
+		}
+		ok, err := keep(item)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return s.less(items[i], items[j]) })
+	return items, nil
+}
+
+// keyOf returns the primary key and the index key of item, which is used as LastEvaluatedKey.
+func (s *source) keyOf(item dynamodb.Item) dynamodb.Item {
+	return project(item, func(name string) bool {
+		return s.t.isKey(name) || name == s.hash || name == s.rng
+	})
+}
+
+// startAfter drops the items up to ExclusiveStartKey from items.
+func (s *source) startAfter(items []dynamodb.Item, start dynamodb.Item, forward bool) ([]dynamodb.Item, error) {
+	if len(start) == 0 {
+		return items, nil
+	}
+	start, err := normalizeItem(start)
+	if err != nil {
+		return nil, err
+	}
+	if len(start) != len(s.keyOf(start)) {
+		return nil, validationError("The provided starting key is invalid: The provided key element does not match the schema")
+	}
+	for _, name := range []string{s.t.hash, s.t.rng, s.hash, s.rng} {
+		if _, ok := start[name]; name != "" && !ok {
+			return nil, validationError("The provided starting key is invalid: The provided key element does not match the schema")
+		}
+	}
+	for i, item := range items {
+		if (forward && s.less(start, item)) || (!forward && s.less(item, start)) {
+			return items[i:], nil
+		}
+	}
+	return nil, nil
+}
+
+// projection returns the attributes to return and whether only the count is requested.
+func (s *source) projection(sel dynamodb.Select, attrs []string) (func(string) bool, bool, error) {
+	if len(attrs) > 0 && sel != "" && sel != dynamodb.SelectSpecific {
+		return nil, false, validationError("Cannot specify the AttributesToGet when choosing to get %s", sel)
+	}
+	switch sel {
+	case "":
+		if len(attrs) > 0 {
+			return attributeFilter(attrs), false, nil
+		}
+		if s.idx != nil {
+			return s.projects, false, nil
+		}
+		return nil, false, nil
+	case dynamodb.SelectAll:
+		if s.idx != nil && s.idx.global && s.idx.projection.ProjectionType != dynamodb.ProjectionTypeAll {
+			return nil, false, validationError("One or more parameter values were invalid: Select type ALL_ATTRIBUTES is not supported for global secondary index %s because its projection type is not ALL", s.idx.name)
+		}
+		return nil, false, nil
+	case dynamodb.SelectAllProjected:
+		if s.idx == nil {
+			return nil, false, validationError("ALL_PROJECTED_ATTRIBUTES can be used only when Querying using an IndexName")
+		}
+		return s.projects, false, nil
+	case dynamodb.SelectSpecific:
+		if len(attrs) == 0 {
+			return nil, false, validationError("SPECIFIC_ATTRIBUTES requires AttributesToGet")
+		}
+		return attributeFilter(attrs), false, nil
+	case dynamodb.SelectCount:
+		return nil, true, nil
+	}
+	return nil, false, validationError("1 validation error detected: Value '%s' at 'select' failed to satisfy constraint: Member must satisfy enum value set: [SPECIFIC_ATTRIBUTES, COUNT, ALL_ATTRIBUTES, ALL_PROJECTED_ATTRIBUTES]", sel)
+}
+
+func (s *source) projects(name string) bool {
+	return s.idx == nil || s.idx.projects(s.t, name)
+}
+
+// page is the parameters shared by Query and Scan.
+type page struct {
+	TableName              string
+	IndexName              string
+	AttributesToGet        []string
+	Select                 dynamodb.Select
+	ConditionalOperator    dynamodb.ConditionalOperator
+	ConsistentRead         bool
+	ExclusiveStartKey      dynamodb.Item
+	Limit                  int
+	ReturnConsumedCapacity dynamodb.ReturnConsumedCapacity
+}
+
+// read evaluates items from the head up to Limit or 1MB and returns the result.
+func (s *source) read(in *page, items []dynamodb.Item, filter map[string]dynamodb.Condition) (map[string]interface{}, error) {
+	if in.Limit < 0 {
+		return nil, validationError("1 validation error detected: Value '%d' at 'limit' failed to satisfy constraint: Member must have value greater than or equal to 1", in.Limit)
+	}
+	keep, count, err := s.projection(in.Select, in.AttributesToGet)
+	if err != nil {
+		return nil, err
+	}
+	filter, err = normalizeConditions(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := map[string]interface{}{}
+	matched := []dynamodb.Item{}
+	var scanned int
+	var size int64
+	for i, item := range items {
+		if (in.Limit > 0 && scanned == in.Limit) || size >= maxPageSize {
+			ret["LastEvaluatedKey"] = s.keyOf(items[i-1])
+			break
+		}
+		scanned++
+		size += itemSize(item)
+
+		// a global secondary index only knows the projected attributes
+		if s.idx != nil && s.idx.global {
+			item = project(item, s.projects)
+		}
+		ok, err := dynamodb.Evaluate(item, filter, in.ConditionalOperator)
+		if err != nil {
+			return nil, conditionError(err)
+		}
+		if ok {
+			matched = append(matched, project(item, keep))
+		}
+	}
+
+	ret["Count"] = len(matched)
+	ret["ScannedCount"] = scanned
+	if !count {
+		ret["Items"] = matched
+	}
+	setCapacity(ret, s.t, in.ReturnConsumedCapacity, readUnits(size, in.ConsistentRead))
+	return ret, nil
+}
+
+type queryInput struct {
+	page
+	KeyConditions    map[string]dynamodb.Condition
+	QueryFilter      map[string]dynamodb.Condition
+	ScanIndexForward *bool
+}
+
+func (h *Handler) query(body []byte) (interface{}, error) {
+	var in queryInput
+	if err := decode(body, &in); err != nil {
+		return nil, err
+	}
+	s, err := h.source(in.TableName, in.IndexName, in.ConsistentRead)
+	if err != nil {
+		return nil, err
+	}
+	conds, err := s.keyConditions(in.KeyConditions)
+	if err != nil {
+		return nil, err
+	}
+	for name := range in.QueryFilter {
+		if name == s.hash || name == s.rng {
+			return nil, validationError("QueryFilter can only contain non-primary key attributes: Primary key attribute: %s", name)
+		}
+	}
+
+	items, err := s.items(func(item dynamodb.Item) (bool, error) {
+		return dynamodb.Evaluate(item, conds, dynamodb.CondOpAnd)
+	})
+	if err != nil {
+		return nil, err
+	}
+	forward := in.ScanIndexForward == nil || *in.ScanIndexForward
+	if !forward {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+	if items, err = s.startAfter(items, in.ExclusiveStartKey, forward); err != nil {
+		return nil, err
+	}
+	return s.read(&in.page, items, in.QueryFilter)
+}
+
+// keyConditions validates that conds has EQ on the hash key and an optional condition on the range key.
+func (s *source) keyConditions(conds map[string]dynamodb.Condition) (map[string]dynamodb.Condition, error) {
+	conds, err := normalizeConditions(conds)
+	if err != nil {
+		return nil, err
+	}
+	hash, ok := conds[s.hash]
+	if !ok {
+		return nil, validationError("Query condition missed key schema element: %s", s.hash)
+	}
+	if hash.ComparisonOperator != dynamodb.CmpOpEQ || hash.AttributeValueList[0].Type != s.t.attrType(s.hash) {
+		return nil, validationError("Query key condition not supported")
+	}
+	for name, cond := range conds {
+		if name == s.hash {
+			continue
+		}
+		if name != s.rng {
+			return nil, validationError("Query condition missed key schema element: %s", s.rng)
+		}
+		switch cond.ComparisonOperator {
+		case dynamodb.CmpOpEQ, dynamodb.CmpOpLE, dynamodb.CmpOpLT, dynamodb.CmpOpGE, dynamodb.CmpOpGT,
+			dynamodb.CmpOpBeginsWith, dynamodb.CmpOpBetween:
+		default:
+			return nil, validationError("Query key condition not supported")
+		}
+		for _, av := range cond.AttributeValueList {
+			if av.Type != s.t.attrType(s.rng) {
+				return nil, validationError("One or more parameter values were invalid: Condition parameter type does not match schema type")
+			}
+		}
+	}
+	return conds, nil
+}
+
+type scanInput struct {
+	page
+	ScanFilter    map[string]dynamodb.Condition
+	Segment       *int
+	TotalSegments *int
+}
+
+func (h *Handler) scan(body []byte) (interface{}, error) {
+	var in scanInput
+	if err := decode(body, &in); err != nil {
+		return nil, err
+	}
+	s, err := h.source(in.TableName, in.IndexName, in.ConsistentRead)
+	if err != nil {
+		return nil, err
+	}
+
+	segment, total := 0, 1
+	if in.TotalSegments != nil {
+		total = *in.TotalSegments
+		if total < 1 || total > 1000000 {
+			return nil, validationError("1 validation error detected: Value '%d' at 'totalSegments' failed to satisfy constraint: Member must have value between 1 and 1000000", total)
+		}
+	}
+	if in.Segment != nil {
+		if in.TotalSegments == nil {
+			return nil, validationError("The TotalSegments parameter is required but was not present in the request when Segment parameter is present")
+		}
+		segment = *in.Segment
+		if segment < 0 || segment >= total {
+			return nil, validationError("The Segment parameter is zero-based and must be less than parameter TotalSegments: Segment: %d is not less than TotalSegments: %d", segment, total)
+		}
+	}
+
+	items, err := s.items(func(item dynamodb.Item) (bool, error) {
+		f := fnv.New32a()
+		f.Write([]byte(keyString(item, s.hash)))
+		return int(f.Sum32()%uint32(total)) == segment, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if items, err = s.startAfter(items, in.ExclusiveStartKey, true); err != nil {
+		return nil, err
+	}
+	return s.read(&in.page, items, in.ScanFilter)
+}
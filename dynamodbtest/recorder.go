@@ -0,0 +1,244 @@
+package dynamodbtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// ErrNoInteraction is returned by Recorder in ModeReplay if no interaction matches a request.
+var ErrNoInteraction = errors.New("dynamodbtest: no recorded interaction")
+
+// Mode tells Recorder whether to record or to replay interactions.
+type Mode int
+
+const (
+	// ModeReplay serves recorded responses without sending requests.
+	ModeReplay Mode = iota
+	// ModeRecord sends requests with Transport and records the responses.
+	ModeRecord
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeReplay:
+		return "replay"
+	case ModeRecord:
+		return "record"
+	}
+	return "Mode(" + strconv.Itoa(int(m)) + ")"
+}
+
+// Interaction is a pair of a request and its response in a fixture file.
+type Interaction struct {
+	Target  string
+	Request json.RawMessage
+
+	StatusCode   int
+	Header       http.Header
+	Uncompressed bool `json:",omitempty"`
+	Response     string
+}
+
+type fixture struct {
+	Interactions []*Interaction
+}
+
+// Recorder is an http.RoundTripper which records DynamoDB requests and responses
+// into a fixture file and replays them, so that tests run offline against the
+// behaviour of a real DynamoDB. Set it to Transport of Client.HTTPClient.
+//
+// Requests are matched on X-Amz-Target and the normalized JSON body; the signature
+// and the other headers are ignored. If the same request is recorded more than once,
+// the responses are replayed in the recorded order and the last one is repeated.
+type Recorder struct {
+	// Filename is the fixture file.
+	Filename string
+
+	// Mode is ModeReplay or ModeRecord.
+	Mode Mode
+
+	// Transport sends requests in ModeRecord. http.DefaultTransport is used if nil.
+	Transport http.RoundTripper
+
+	// IgnoreFields are removed from request bodies before matching such as
+	// "ClientRequestToken" which differs on every run.
+	IgnoreFields []string
+
+	mu           sync.Mutex
+	interactions []*Interaction
+	replayed     map[string]int
+}
+
+// NewRecorder returns a Recorder for filename. The fixture file is loaded in ModeReplay.
+func NewRecorder(filename string, mode Mode) (*Recorder, error) {
+	r := &Recorder{
+		Filename: filename,
+		Mode:     mode,
+	}
+	if mode == ModeReplay {
+		if err := r.load(); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (r *Recorder) load() error {
+	data, err := ioutil.ReadFile(r.Filename)
+	if err != nil {
+		return err
+	}
+	var f fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("dynamodbtest: failed to load %s: %w", r.Filename, err)
+	}
+	r.mu.Lock()
+	r.interactions = f.Interactions
+	r.replayed = map[string]int{}
+	r.mu.Unlock()
+	return nil
+}
+
+// Save writes the recorded interactions into the fixture file.
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	data, err := json.MarshalIndent(fixture{r.interactions}, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.Filename, append(data, '\n'), 0644)
+}
+
+// Interactions returns the interactions recorded or loaded so far.
+func (r *Recorder) Interactions() []*Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Interaction(nil), r.interactions...)
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	target := req.Header.Get("X-Amz-Target")
+	normalized, err := r.normalize(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Mode == ModeRecord {
+		return r.record(req, body, target, normalized)
+	}
+	return r.replay(req, target, normalized)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte, target string, normalized json.RawMessage) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	req = req.Clone(req.Context())
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, &Interaction{
+		Target:       target,
+		Request:      normalized,
+		StatusCode:   resp.StatusCode,
+		Header:       resp.Header.Clone(),
+		Uncompressed: resp.Uncompressed,
+		Response:     string(respBody),
+	})
+	r.mu.Unlock()
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, target string, normalized json.RawMessage) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matched []*Interaction
+	for _, i := range r.interactions {
+		if i.Target != target {
+			continue
+		}
+		// the fixture file may be indented or edited by hand
+		recorded, err := r.normalize(i.Request)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(recorded, normalized) {
+			matched = append(matched, i)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, target, normalized)
+	}
+	k := target + "\x00" + string(normalized)
+	n := r.replayed[k]
+	if n >= len(matched) {
+		n = len(matched) - 1
+	}
+	r.replayed[k]++
+	i := matched[n]
+
+	header := i.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Del("Content-Length")
+	return &http.Response{
+		Status:        strconv.Itoa(i.StatusCode) + " " + http.StatusText(i.StatusCode),
+		StatusCode:    i.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader([]byte(i.Response))),
+		ContentLength: int64(len(i.Response)),
+		Uncompressed:  i.Uncompressed,
+		Request:       req,
+	}, nil
+}
+
+// normalize re-encodes body in the compact form with sorted keys without IgnoreFields.
+func (r *Recorder) normalize(body []byte) (json.RawMessage, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return json.RawMessage("{}"), nil
+	}
+	var v interface{}
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return nil, fmt.Errorf("dynamodbtest: request body is not JSON: %w", err)
+	}
+	if m, ok := v.(map[string]interface{}); ok {
+		for _, f := range r.IgnoreFields {
+			delete(m, f)
+		}
+	}
+	return json.Marshal(v)
+}
@@ -0,0 +1,93 @@
+package dynamodbtest_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nabeken/goamz-dynamodb"
+	"github.com/nabeken/goamz-dynamodb/dynamodbtest"
+)
+
+func TestRecorder(t *testing.T) {
+	fixture := filepath.Join(t.TempDir(), "fixture.json")
+
+	srv, c := newServer(t)
+	rec, err := dynamodbtest.NewRecorder(fixture, dynamodbtest.ModeRecord)
+	if !assert.NoError(t, err) {
+		return
+	}
+	rec.Transport = srv.Client().Transport
+	c.HTTPClient.Transport = rec
+
+	_, err = c.PutItem("Messages", message("alice", 1, "Body", "hello"), nil)
+	assert.NoError(t, err)
+	get := func() (*dynamodb.GetItemResult, error) {
+		return c.GetItem("Messages", key("alice", 1), nil)
+	}
+	_, err = get()
+	assert.NoError(t, err)
+	_, err = c.DeleteItem("Messages", key("alice", 1), nil)
+	assert.NoError(t, err)
+	_, err = get()
+	assert.NoError(t, err)
+	_, err = c.DescribeTable("Unknown")
+	assert.True(t, errors.Is(err, dynamodb.ErrResourceNotFound))
+	assert.NoError(t, rec.Save())
+	assert.Len(t, rec.Interactions(), 5)
+
+	// replay without the server
+	srv.Close()
+	rec, err = dynamodbtest.NewRecorder(fixture, dynamodbtest.ModeReplay)
+	if !assert.NoError(t, err) {
+		return
+	}
+	c.HTTPClient.Transport = rec
+
+	_, err = c.PutItem("Messages", message("alice", 1, "Body", "hello"), nil)
+	assert.NoError(t, err)
+
+	// the same request is replayed in the recorded order
+	ret, err := get()
+	if assert.NoError(t, err) {
+		assert.Equal(t, dynamodb.NewString("hello"), ret.Item["Body"])
+	}
+	ret, err = get()
+	if assert.NoError(t, err) {
+		assert.Nil(t, ret.Item)
+	}
+	_, err = c.DescribeTable("Unknown")
+	assert.True(t, errors.Is(err, dynamodb.ErrResourceNotFound))
+
+	c.Retryer = dynamodb.NoOpRetryer{}
+	_, err = c.GetItem("Messages", key("bob", 1), nil)
+	assert.True(t, errors.Is(err, dynamodbtest.ErrNoInteraction))
+}
+
+func TestRecorder_IgnoreFields(t *testing.T) {
+	fixture := filepath.Join(t.TempDir(), "fixture.json")
+
+	srv, c := newServer(t)
+	rec, _ := dynamodbtest.NewRecorder(fixture, dynamodbtest.ModeRecord)
+	rec.Transport = srv.Client().Transport
+	rec.IgnoreFields = []string{"ReturnConsumedCapacity"}
+	c.HTTPClient.Transport = rec
+
+	_, err := c.GetItem("Messages", key("alice", 1), &dynamodb.GetItemOption{ReturnConsumedCapacity: dynamodb.ConsumedCapTotal})
+	assert.NoError(t, err)
+	assert.NoError(t, rec.Save())
+
+	rec, err = dynamodbtest.NewRecorder(fixture, dynamodbtest.ModeReplay)
+	if !assert.NoError(t, err) {
+		return
+	}
+	rec.IgnoreFields = []string{"ReturnConsumedCapacity"}
+	c.HTTPClient.Transport = rec
+	_, err = c.GetItem("Messages", key("alice", 1), &dynamodb.GetItemOption{ReturnConsumedCapacity: dynamodb.ConsumedCapIndexes})
+	assert.NoError(t, err)
+
+	_, err = dynamodbtest.NewRecorder(filepath.Join(t.TempDir(), "missing.json"), dynamodbtest.ModeReplay)
+	assert.Error(t, err)
+}
@@ -0,0 +1,213 @@
+// Package dynamodbtest provides an in-memory DynamoDB server and HTTP transports for tests.
+//
+// The server speaks the DynamoDB JSON protocol over HTTP, so a Client talks
+// to it exactly as it does to DynamoDB. It supports table lifecycle, hash and
+// range keys, global and local secondary indexes, GetItem, PutItem, UpdateItem,
+// DeleteItem, Query, Scan, BatchGetItem, BatchWriteItem and conditional writes
+// with the Expected, KeyConditions, QueryFilter and ScanFilter parameters.
+// Tables and indexes are ACTIVE as soon as they are created.
+//
+// Recorder records interactions with a real DynamoDB into a fixture file and
+// replays them later without network access. FaultInjector injects errors and
+// latency into requests to test retries.
+package dynamodbtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nabeken/goamz-dynamodb"
+)
+
+const targetPrefix = "DynamoDB_20120810."
+
+// Error codes returned by the server in addition to the ones defined in dynamodb.
+const (
+	CodeSerialization    = "SerializationException"
+	CodeUnknownOperation = "UnknownOperationException"
+)
+
+// Server is an in-memory DynamoDB running on httptest.Server.
+type Server struct {
+	*httptest.Server
+	Handler *Handler
+}
+
+// NewServer starts a Server. The caller should call Close when finished.
+func NewServer() *Server {
+	h := NewHandler()
+	return &Server{
+		Server:  httptest.NewServer(h),
+		Handler: h,
+	}
+}
+
+// NewClient returns a Client which sends requests to the server with dummy credentials.
+func (s *Server) NewClient() *dynamodb.Client {
+	return &dynamodb.Client{
+		Auth:       dynamodb.Auth{AccessKey: "DUMMY_KEY", SecretKey: "DUMMY_SECRET"},
+		Region:     dynamodb.Region{Name: dynamodb.DefaultSigningRegion, DynamoDBEndpoint: s.URL},
+		HTTPClient: *s.Client(),
+	}
+}
+
+// Handler serves the DynamoDB API from memory. Requests are processed one at a time.
+type Handler struct {
+	mu     sync.Mutex
+	tables map[string]*table
+
+	requestID uint64
+}
+
+// NewHandler returns an empty Handler.
+func NewHandler() *Handler {
+	return &Handler{tables: map[string]*table{}}
+}
+
+// Reset deletes all tables.
+func (h *Handler) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.tables = map[string]*table{}
+}
+
+type operation func(h *Handler, body []byte) (interface{}, error)
+
+var operations = map[string]operation{
+	"BatchGetItem":       (*Handler).batchGetItem,
+	"BatchWriteItem":     (*Handler).batchWriteItem,
+	"CreateTable":        (*Handler).createTable,
+	"DeleteItem":         (*Handler).deleteItem,
+	"DeleteTable":        (*Handler).deleteTable,
+	"DescribeLimits":     (*Handler).describeLimits,
+	"DescribeTable":      (*Handler).describeTable,
+	"GetItem":            (*Handler).getItem,
+	"ListTables":         (*Handler).listTables,
+	"ListTagsOfResource": (*Handler).listTagsOfResource,
+	"PutItem":            (*Handler).putItem,
+	"Query":              (*Handler).query,
+	"Scan":               (*Handler).scan,
+	"TagResource":        (*Handler).tagResource,
+	"UntagResource":      (*Handler).untagResource,
+	"UpdateItem":         (*Handler).updateItem,
+	"UpdateTable":        (*Handler).updateTable,
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := fmt.Sprintf("%016X", atomic.AddUint64(&h.requestID, 1))
+
+	if r.Method != "POST" {
+		writeJSON(w, requestID, http.StatusMethodNotAllowed, newError(CodeUnknownOperation, "Only POST is supported"))
+		return
+	}
+	target := r.Header.Get("X-Amz-Target")
+	op, ok := operations[strings.TrimPrefix(target, targetPrefix)]
+	if !ok || !strings.HasPrefix(target, targetPrefix) {
+		writeJSON(w, requestID, http.StatusBadRequest, newError(CodeUnknownOperation, "Unsupported operation: "+target))
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeJSON(w, requestID, http.StatusBadRequest, newError(CodeSerialization, err.Error()))
+		return
+	}
+
+	h.mu.Lock()
+	ret, err := op(h, body)
+	h.mu.Unlock()
+
+	if err != nil {
+		e, ok := err.(*apiError)
+		if !ok {
+			e = newError(dynamodb.CodeInternalServerError, err.Error())
+		}
+		writeJSON(w, requestID, e.status(), e)
+		return
+	}
+	writeJSON(w, requestID, http.StatusOK, ret)
+}
+
+func writeJSON(w http.ResponseWriter, requestID string, status int, v interface{}) {
+	j, err := json.Marshal(v)
+	if err != nil {
+		status = http.StatusInternalServerError
+		j, _ = json.Marshal(newError(dynamodb.CodeInternalServerError, err.Error()))
+	}
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	w.Header().Set("X-Amzn-Requestid", requestID)
+	w.Header().Set("X-Amz-Crc32", strconv.FormatUint(uint64(crc32.ChecksumIEEE(j)), 10))
+	w.WriteHeader(status)
+	w.Write(j)
+}
+
+// apiError is encoded as DynamoDB encodes an error.
+type apiError struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+func newError(code, format string, args ...interface{}) *apiError {
+	msg := format
+	if len(args) > 0 {
+		msg = fmt.Sprintf(format, args...)
+	}
+	return &apiError{
+		Type:    "com.amazonaws.dynamodb.v20120810#" + code,
+		Message: msg,
+	}
+}
+
+func validationError(format string, args ...interface{}) *apiError {
+	return newError(dynamodb.CodeValidation, format, args...)
+}
+
+func (e *apiError) code() string {
+	return e.Type[strings.LastIndex(e.Type, "#")+1:]
+}
+
+func (e *apiError) status() int {
+	if e.code() == dynamodb.CodeInternalServerError {
+		return http.StatusInternalServerError
+	}
+	return http.StatusBadRequest
+}
+
+func (e *apiError) Error() string {
+	return e.code() + ": " + e.Message
+}
+
+// decode decodes body into v or returns SerializationException.
+func decode(body []byte, v interface{}) error {
+	if len(body) == 0 {
+		body = []byte("{}")
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return newError(CodeSerialization, err.Error())
+	}
+	return nil
+}
+
+// lookup returns the table or ResourceNotFoundException.
+func (h *Handler) lookup(name string) (*table, error) {
+	if name == "" {
+		return nil, validationError("1 validation error detected: Value null at 'tableName' failed to satisfy constraint: Member must not be null")
+	}
+	t, ok := h.tables[name]
+	if !ok {
+		return nil, newError(dynamodb.CodeResourceNotFound, "Requested resource not found: Table: %s not found", name)
+	}
+	return t, nil
+}
+
+func epoch(t time.Time) float64 {
+	return float64(t.UnixNano()/int64(time.Millisecond)) / 1000
+}
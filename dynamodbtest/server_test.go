@@ -0,0 +1,440 @@
+package dynamodbtest_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nabeken/goamz-dynamodb"
+	"github.com/nabeken/goamz-dynamodb/dynamodbtest"
+)
+
+func newServer(t *testing.T) (*dynamodbtest.Server, *dynamodb.Client) {
+	srv := dynamodbtest.NewServer()
+	t.Cleanup(srv.Close)
+
+	c := srv.NewClient()
+	c.Retryer = dynamodb.NoOpRetryer{}
+	_, err := c.CreateTable(&dynamodb.Table{
+		Name: "Messages",
+		AttributeDefinitions: []dynamodb.AttributeDefinition{
+			{Name: "UserId", Type: dynamodb.TypeString},
+			{Name: "PostedAt", Type: dynamodb.TypeNumber},
+			{Name: "Topic", Type: dynamodb.TypeString},
+			{Name: "Score", Type: dynamodb.TypeNumber},
+		},
+		KeySchema: []dynamodb.KeySchemaElement{
+			{AttributeName: "UserId", KeyType: dynamodb.KeyTypeHash},
+			{AttributeName: "PostedAt", KeyType: dynamodb.KeyTypeRange},
+		},
+		ProvisionedThroughput: dynamodb.ProvisionedThroughput{ReadCapacityUnits: 5, WriteCapacityUnits: 5},
+	}, &dynamodb.TableOption{
+		GlobalSecondaryIndexes: []dynamodb.GlobalSecondaryIndex{{
+			IndexName: "TopicIndex",
+			KeySchema: []dynamodb.KeySchemaElement{
+				{AttributeName: "Topic", KeyType: dynamodb.KeyTypeHash},
+			},
+			Projection:            dynamodb.Projection{ProjectionType: dynamodb.ProjectionTypeKeysOnly},
+			ProvisionedThroughput: dynamodb.ProvisionedThroughput{ReadCapacityUnits: 5, WriteCapacityUnits: 5},
+		}},
+		LocalSecondaryIndexes: []dynamodb.LocalSecondaryIndex{{
+			IndexName: "ScoreIndex",
+			KeySchema: []dynamodb.KeySchemaElement{
+				{AttributeName: "UserId", KeyType: dynamodb.KeyTypeHash},
+				{AttributeName: "Score", KeyType: dynamodb.KeyTypeRange},
+			},
+			Projection: dynamodb.Projection{ProjectionType: dynamodb.ProjectionTypeAll},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv, c
+}
+
+func message(user string, postedAt int, attrs ...interface{}) dynamodb.Item {
+	item := dynamodb.Item{
+		"UserId":   dynamodb.NewString(user),
+		"PostedAt": dynamodb.NewNumber(postedAt),
+	}
+	for i := 0; i < len(attrs); i += 2 {
+		av, _ := dynamodb.NewAttributeValue(attrs[i+1])
+		item[attrs[i].(string)] = av
+	}
+	return item
+}
+
+func key(user string, postedAt int) dynamodb.Item {
+	return message(user, postedAt)
+}
+
+func TestServer_Table(t *testing.T) {
+	_, c := newServer(t)
+
+	_, err := c.CreateTable(&dynamodb.Table{
+		Name:                  "Messages",
+		AttributeDefinitions:  []dynamodb.AttributeDefinition{{Name: "UserId", Type: dynamodb.TypeString}},
+		KeySchema:             []dynamodb.KeySchemaElement{{AttributeName: "UserId", KeyType: dynamodb.KeyTypeHash}},
+		ProvisionedThroughput: dynamodb.ProvisionedThroughput{ReadCapacityUnits: 1, WriteCapacityUnits: 1},
+	}, nil)
+	assert.True(t, errors.Is(err, dynamodb.ErrResourceInUse))
+
+	_, err = c.PutItem("Messages", message("alice", 1, "Topic", "go"), nil)
+	assert.NoError(t, err)
+
+	td, err := c.DescribeTable("Messages")
+	if assert.NoError(t, err) {
+		assert.Equal(t, dynamodb.TableStatusActive, td.Table.TableStatus)
+		assert.Equal(t, int64(1), td.Table.ItemCount)
+		assert.Equal(t, "TopicIndex", td.Table.GlobalSecondaryIndexes[0].IndexName)
+		assert.Equal(t, int64(1), td.Table.GlobalSecondaryIndexes[0].ItemCount)
+		assert.Equal(t, "ScoreIndex", td.Table.LocalSecondaryIndexes[0].IndexName)
+		assert.Equal(t, int64(0), td.Table.LocalSecondaryIndexes[0].ItemCount)
+	}
+
+	_, err = c.UpdateTable("Messages", &dynamodb.UpdateTableOption{
+		ProvisionedThroughput: dynamodb.ProvisionedThroughput{ReadCapacityUnits: 10, WriteCapacityUnits: 20},
+	})
+	assert.NoError(t, err)
+	td, _ = c.DescribeTable("Messages")
+	assert.Equal(t, int64(20), td.Table.ProvisionedThroughput.WriteCapacityUnits)
+
+	assert.NoError(t, c.TagResource(td.Table.TableArn, []dynamodb.Tag{{Key: "env", Value: "test"}}))
+	tags, err := c.ListTagsOfResource(td.Table.TableArn, nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []dynamodb.Tag{{Key: "env", Value: "test"}}, tags.Tags)
+	}
+
+	_, err = c.DeleteTable("Messages")
+	assert.NoError(t, err)
+	_, err = c.DescribeTable("Messages")
+	assert.True(t, errors.Is(err, dynamodb.ErrResourceNotFound))
+	lt, err := c.ListTables(nil)
+	if assert.NoError(t, err) {
+		assert.Empty(t, lt.TableNames)
+	}
+}
+
+func TestServer_ConditionalWrite(t *testing.T) {
+	_, c := newServer(t)
+
+	notExists := &dynamodb.PutItemOption{
+		Expected: dynamodb.ExpectedAttributeValue{
+			"UserId": {ComparisonOperator: dynamodb.CmpOpNull},
+		},
+	}
+	_, err := c.PutItem("Messages", message("alice", 1, "Body", "hello", "Version", 1), notExists)
+	assert.NoError(t, err)
+	_, err = c.PutItem("Messages", message("alice", 1, "Body", "hello again"), notExists)
+	assert.True(t, errors.Is(err, dynamodb.ErrConditionalCheckFailed))
+
+	ret, err := c.UpdateItem("Messages", key("alice", 1), &dynamodb.UpdateItemOption{
+		Expected: dynamodb.ExpectedAttributeValue{
+			"Version": {ComparisonOperator: dynamodb.CmpOpEQ, AttributeValueList: []dynamodb.AttributeValue{dynamodb.NewNumber(1)}},
+		},
+		AttributeUpdates: map[string]dynamodb.AttributeUpdate{
+			"Version": {Action: dynamodb.ActionAdd, Value: dynamodb.NewNumber(1)},
+			"Tags":    {Action: dynamodb.ActionAdd, Value: dynamodb.NewStringSet("a", "b")},
+			"Body":    {Action: dynamodb.ActionDelete},
+		},
+		ReturnValues: dynamodb.ReturnValuesAllNew,
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, dynamodb.NewNumber(2), ret.Attributes["Version"])
+		assert.Equal(t, dynamodb.NewStringSet("a", "b"), ret.Attributes["Tags"])
+		assert.NotContains(t, ret.Attributes, "Body")
+	}
+
+	_, err = c.DeleteItem("Messages", key("alice", 1), &dynamodb.DeleteItemOption{
+		Expected: dynamodb.ExpectedAttributeValue{
+			"Version": {ComparisonOperator: dynamodb.CmpOpLT, AttributeValueList: []dynamodb.AttributeValue{dynamodb.NewNumber(2)}},
+		},
+	})
+	assert.True(t, errors.Is(err, dynamodb.ErrConditionalCheckFailed))
+
+	dret, err := c.DeleteItem("Messages", key("alice", 1), &dynamodb.DeleteItemOption{
+		Expected: dynamodb.ExpectedAttributeValue{
+			"Tags": {ComparisonOperator: dynamodb.CmpOpContains, AttributeValueList: []dynamodb.AttributeValue{dynamodb.NewString("b")}},
+		},
+		ReturnValues: dynamodb.ReturnValuesAllOld,
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, dynamodb.NewNumber(2), dret.Attributes["Version"])
+	}
+	gret, err := c.GetItem("Messages", key("alice", 1), nil)
+	if assert.NoError(t, err) {
+		assert.Nil(t, gret.Item)
+	}
+}
+
+func TestServer_Validation(t *testing.T) {
+	_, c := newServer(t)
+
+	for name, f := range map[string]func() error{
+		"missing range key": func() error {
+			_, err := c.PutItem("Messages", dynamodb.Item{"UserId": dynamodb.NewString("alice")}, nil)
+			return err
+		},
+		"key type mismatch": func() error {
+			_, err := c.GetItem("Messages", dynamodb.Item{"UserId": dynamodb.NewString("alice"), "PostedAt": dynamodb.NewString("1")}, nil)
+			return err
+		},
+		"index key type mismatch": func() error {
+			_, err := c.PutItem("Messages", message("alice", 1, "Topic", 1), nil)
+			return err
+		},
+		"invalid number": func() error {
+			_, err := c.PutItem("Messages", message("alice", 1, "Score", dynamodb.AttributeValue{Type: dynamodb.TypeNumber, Data: []dynamodb.AttributeData{"one"}}), nil)
+			return err
+		},
+		"invalid number in map": func() error {
+			_, err := c.PutItem("Messages", message("alice", 1, "Doc", dynamodb.NewMap(map[string]dynamodb.AttributeValue{
+				"Score": {Type: dynamodb.TypeNumber, Data: []dynamodb.AttributeData{"0x10"}},
+			})), nil)
+			return err
+		},
+		"update key": func() error {
+			_, err := c.UpdateItem("Messages", key("alice", 1), &dynamodb.UpdateItemOption{
+				AttributeUpdates: map[string]dynamodb.AttributeUpdate{
+					"PostedAt": {Action: dynamodb.ActionPut, Value: dynamodb.NewNumber(2)},
+				},
+			})
+			return err
+		},
+		"invalid argument count": func() error {
+			_, err := c.Scan("Messages", &dynamodb.ScanOption{
+				ScanFilter: dynamodb.ScanFilter{"Score": {ComparisonOperator: dynamodb.CmpOpBetween, AttributeValueList: []dynamodb.AttributeValue{dynamodb.NewNumber(1)}}},
+			})
+			return err
+		},
+		"query without hash key": func() error {
+			_, err := c.Query("Messages", &dynamodb.KeyConditions{
+				"PostedAt": {ComparisonOperator: dynamodb.CmpOpEQ, AttributeValueList: []dynamodb.AttributeValue{dynamodb.NewNumber(1)}},
+			}, nil)
+			return err
+		},
+		"consistent read on GSI": func() error {
+			_, err := c.Query("Messages", &dynamodb.KeyConditions{
+				"Topic": {ComparisonOperator: dynamodb.CmpOpEQ, AttributeValueList: []dynamodb.AttributeValue{dynamodb.NewString("go")}},
+			}, &dynamodb.QueryOption{IndexName: "TopicIndex", ConsistentRead: true})
+			return err
+		},
+	} {
+		err := f()
+		assert.True(t, errors.Is(err, dynamodb.ErrValidation), "%s: %v", name, err)
+	}
+
+	_, err := c.GetItem("Unknown", key("alice", 1), nil)
+	assert.True(t, errors.Is(err, dynamodb.ErrResourceNotFound))
+}
+
+func TestServer_Query(t *testing.T) {
+	_, c := newServer(t)
+
+	for i := 0; i < 10; i++ {
+		attrs := []interface{}{"Score", 10 - i}
+		if i%2 == 0 {
+			attrs = append(attrs, "Topic", "go")
+		}
+		_, err := c.PutItem("Messages", message("alice", i, attrs...), nil)
+		assert.NoError(t, err)
+	}
+	_, err := c.PutItem("Messages", message("bob", 0, "Topic", "go"), nil)
+	assert.NoError(t, err)
+
+	alice := dynamodb.KeyConditions{
+		"UserId":   {ComparisonOperator: dynamodb.CmpOpEQ, AttributeValueList: []dynamodb.AttributeValue{dynamodb.NewString("alice")}},
+		"PostedAt": {ComparisonOperator: dynamodb.CmpOpBetween, AttributeValueList: []dynamodb.AttributeValue{dynamodb.NewNumber(2), dynamodb.NewNumber(8)}},
+	}
+
+	// pages in the order of the range key
+	var postedAt []string
+	qopt := &dynamodb.QueryOption{Limit: 3}
+	for {
+		ret, err := c.Query("Messages", &alice, qopt)
+		if !assert.NoError(t, err) {
+			return
+		}
+		for _, item := range ret.Items {
+			postedAt = append(postedAt, string(item["PostedAt"].Data[0]))
+		}
+		if len(ret.LastEvaluatedKey) == 0 {
+			break
+		}
+		qopt.ExclusiveStartKey = ret.LastEvaluatedKey
+	}
+	assert.Equal(t, []string{"2", "3", "4", "5", "6", "7", "8"}, postedAt)
+
+	// the local secondary index sorts by Score
+	ret, err := c.Query("Messages", &dynamodb.KeyConditions{
+		"UserId": {ComparisonOperator: dynamodb.CmpOpEQ, AttributeValueList: []dynamodb.AttributeValue{dynamodb.NewString("alice")}},
+		"Score":  {ComparisonOperator: dynamodb.CmpOpGT, AttributeValueList: []dynamodb.AttributeValue{dynamodb.NewNumber(7)}},
+	}, &dynamodb.QueryOption{IndexName: "ScoreIndex", Limit: 1})
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(1), ret.Count)
+		assert.Equal(t, "2", string(ret.Items[0]["PostedAt"].Data[0]))
+		assert.Len(t, ret.LastEvaluatedKey, 3)
+	}
+
+	// the global secondary index is sparse and projects the keys only
+	ret, err = c.Query("Messages", &dynamodb.KeyConditions{
+		"Topic": {ComparisonOperator: dynamodb.CmpOpEQ, AttributeValueList: []dynamodb.AttributeValue{dynamodb.NewString("go")}},
+	}, &dynamodb.QueryOption{IndexName: "TopicIndex"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(6), ret.Count)
+		assert.NotContains(t, ret.Items[0], "Score")
+	}
+
+	// QueryFilter applies after Limit
+	ret, err = c.Query("Messages", &alice, &dynamodb.QueryOption{
+		Limit:       4,
+		QueryFilter: dynamodb.QueryFilter{"Topic": {ComparisonOperator: dynamodb.CmpOpNotNull}},
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(2), ret.Count)
+		assert.Equal(t, int64(4), ret.ScannedCount)
+	}
+}
+
+func TestServer_Scan(t *testing.T) {
+	_, c := newServer(t)
+
+	for i := 0; i < 20; i++ {
+		_, err := c.PutItem("Messages", message("user"+strconv.Itoa(i), i, "Score", i), nil)
+		assert.NoError(t, err)
+	}
+
+	ret, err := c.Scan("Messages", &dynamodb.ScanOption{
+		ScanFilter: dynamodb.ScanFilter{"Score": {ComparisonOperator: dynamodb.CmpOpGE, AttributeValueList: []dynamodb.AttributeValue{dynamodb.NewNumber(15)}}},
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(5), ret.Count)
+		assert.Equal(t, int64(20), ret.ScannedCount)
+	}
+
+	var total int64
+	for segment := uint(0); segment < 4; segment++ {
+		ret, err := c.Scan("Messages", &dynamodb.ScanOption{Segment: segment, TotalSegments: 4, Select: dynamodb.SelectCount})
+		if assert.NoError(t, err) {
+			assert.Empty(t, ret.Items)
+			total += ret.Count
+		}
+	}
+	assert.Equal(t, int64(20), total)
+}
+
+func TestServer_Batch(t *testing.T) {
+	_, c := newServer(t)
+
+	_, err := c.BatchWriteItem(map[string][]dynamodb.WriteRequest{
+		"Messages": {
+			{PutRequest: dynamodb.PutRequest{Item: message("alice", 1)}},
+			{PutRequest: dynamodb.PutRequest{Item: message("alice", 2)}},
+			{PutRequest: dynamodb.PutRequest{Item: message("alice", 3)}},
+		},
+	}, nil)
+	assert.NoError(t, err)
+
+	_, err = c.BatchWriteItem(map[string][]dynamodb.WriteRequest{
+		"Messages": {
+			{DeleteRequest: dynamodb.DeleteRequest{Key: key("alice", 1)}},
+			{PutRequest: dynamodb.PutRequest{Item: message("alice", 1, "Body", "dup")}},
+		},
+	}, nil)
+	assert.True(t, errors.Is(err, dynamodb.ErrValidation))
+
+	_, err = c.BatchWriteItem(map[string][]dynamodb.WriteRequest{
+		"Messages": {{DeleteRequest: dynamodb.DeleteRequest{Key: key("alice", 1)}}},
+	}, nil)
+	assert.NoError(t, err)
+
+	ret, err := c.BatchGetItem(map[string]dynamodb.KeysAndAttributes{
+		"Messages": {Keys: []map[string]dynamodb.AttributeValue{key("alice", 1), key("alice", 2), key("alice", 3)}},
+	}, nil)
+	if assert.NoError(t, err) {
+		assert.Len(t, ret.Responses["Messages"], 2)
+		assert.Empty(t, ret.UnprocessedKeys)
+	}
+}
+
+func TestServer_InvalidNumber(t *testing.T) {
+	_, c := newServer(t)
+
+	for _, n := range []string{"0x10", "0b11", "1/3", "010", " 5 ", "1e126", "1234567890123456789012345678901234567890"} {
+		_, err := c.PutItem("Messages", message("alice", 1, "Score", dynamodb.AttributeValue{Type: dynamodb.TypeNumber, Data: []dynamodb.AttributeData{dynamodb.AttributeData(n)}}), nil)
+		assert.True(t, errors.Is(err, dynamodb.ErrValidation), "%q: %v", n, err)
+	}
+	ret, err := c.Scan("Messages", nil)
+	if assert.NoError(t, err) {
+		assert.Empty(t, ret.Items)
+	}
+}
+
+func TestServer_Document(t *testing.T) {
+	_, c := newServer(t)
+
+	doc := dynamodb.NewMap(map[string]dynamodb.AttributeValue{
+		"Title": dynamodb.NewString("hello"),
+		"Score": {Type: dynamodb.TypeNumber, Data: []dynamodb.AttributeData{"1.50"}},
+	})
+	_, err := c.PutItem("Messages", message("alice", 1,
+		"Doc", doc,
+		"Tags", dynamodb.NewList(dynamodb.NewString("a"), dynamodb.NewNumber(1)),
+	), nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// nested values are normalized and compared by value
+	expected := dynamodb.ExpectedAttributeValue{
+		"Doc":  {ComparisonOperator: dynamodb.CmpOpEQ, AttributeValueList: []dynamodb.AttributeValue{doc}},
+		"Tags": {ComparisonOperator: dynamodb.CmpOpContains, AttributeValueList: []dynamodb.AttributeValue{dynamodb.NewString("a")}},
+	}
+	// maps and lists are kept on an update of another attribute and a list is appended by ADD
+	uret, err := c.UpdateItem("Messages", key("alice", 1), &dynamodb.UpdateItemOption{
+		Expected: expected,
+		AttributeUpdates: map[string]dynamodb.AttributeUpdate{
+			"Version": {Action: dynamodb.ActionPut, Value: dynamodb.NewNumber(1)},
+			"Tags":    {Action: dynamodb.ActionAdd, Value: dynamodb.NewList(dynamodb.NewBool(true))},
+		},
+		ReturnValues: dynamodb.ReturnValuesAllNew,
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, dynamodb.NewMap(map[string]dynamodb.AttributeValue{
+			"Title": dynamodb.NewString("hello"),
+			"Score": {Type: dynamodb.TypeNumber, Data: []dynamodb.AttributeData{"1.5"}},
+		}), uret.Attributes["Doc"])
+		assert.Equal(t, dynamodb.NewList(dynamodb.NewString("a"), dynamodb.NewNumber(1), dynamodb.NewBool(true)), uret.Attributes["Tags"])
+	}
+
+	_, err = c.DeleteItem("Messages", key("alice", 1), &dynamodb.DeleteItemOption{
+		Expected: dynamodb.ExpectedAttributeValue{
+			"Doc": {ComparisonOperator: dynamodb.CmpOpEQ, AttributeValueList: []dynamodb.AttributeValue{
+				dynamodb.NewMap(map[string]dynamodb.AttributeValue{"Title": dynamodb.NewString("hello")}),
+			}},
+		},
+	})
+	assert.True(t, errors.Is(err, dynamodb.ErrConditionalCheckFailed), "%v", err)
+
+	gret, err := c.GetItem("Messages", key("alice", 1), nil)
+	if assert.NoError(t, err) {
+		assert.Len(t, gret.Item["Tags"].List, 3)
+	}
+}
+
+func TestServer_NumberNormalization(t *testing.T) {
+	_, c := newServer(t)
+
+	item := message("alice", 1)
+	item["PostedAt"] = dynamodb.AttributeValue{Type: dynamodb.TypeNumber, Data: []dynamodb.AttributeData{"1.00"}}
+	item["Price"] = dynamodb.AttributeValue{Type: dynamodb.TypeNumber, Data: []dynamodb.AttributeData{"12.50"}}
+	_, err := c.PutItem("Messages", item, nil)
+	assert.NoError(t, err)
+
+	ret, err := c.GetItem("Messages", key("alice", 1), nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "12.5", string(ret.Item["Price"].Data[0]))
+	}
+}
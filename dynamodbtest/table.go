@@ -0,0 +1,474 @@
+package dynamodbtest
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nabeken/goamz-dynamodb"
+)
+
+const arnPrefix = "arn:aws:dynamodb:ddblocal:000000000000:table/"
+
+// maxListTables is the maximum of Limit in ListTables.
+const maxListTables = 100
+
+var tableNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]{3,255}$`)
+
+type index struct {
+	name       string
+	global     bool
+	hash, rng  string
+	projection dynamodb.Projection
+	throughput dynamodb.ProvisionedThroughput
+}
+
+// projects reports whether the index has the attribute.
+func (idx *index) projects(t *table, name string) bool {
+	switch {
+	case idx.projection.ProjectionType == dynamodb.ProjectionTypeAll:
+		return true
+	case t.isKey(name) || name == idx.hash || name == idx.rng:
+		return true
+	case idx.projection.ProjectionType == dynamodb.ProjectionTypeInclude:
+		for _, a := range idx.projection.NonKeyAttributes {
+			if a == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type table struct {
+	name       string
+	created    time.Time
+	attrs      []dynamodb.AttributeDefinition
+	hash, rng  string
+	throughput dynamodb.ProvisionedThroughput
+	indexes    []*index
+	tags       []dynamodb.Tag
+
+	// items are keyed by keyString of the primary key.
+	items map[string]dynamodb.Item
+}
+
+func (t *table) arn() string {
+	return arnPrefix + t.name
+}
+
+func (t *table) isKey(name string) bool {
+	return name == t.hash || (t.rng != "" && name == t.rng)
+}
+
+func (t *table) attrType(name string) dynamodb.AttributeType {
+	for _, ad := range t.attrs {
+		if ad.Name == name {
+			return ad.Type
+		}
+	}
+	return ""
+}
+
+func (t *table) index(name string) (*index, error) {
+	for _, idx := range t.indexes {
+		if idx.name == name {
+			return idx, nil
+		}
+	}
+	return nil, validationError("The table does not have the specified index: %s", name)
+}
+
+func (t *table) keySchema(hash, rng string) []dynamodb.KeySchemaElement {
+	ks := []dynamodb.KeySchemaElement{{AttributeName: hash, KeyType: dynamodb.KeyTypeHash}}
+	if rng != "" {
+		ks = append(ks, dynamodb.KeySchemaElement{AttributeName: rng, KeyType: dynamodb.KeyTypeRange})
+	}
+	return ks
+}
+
+func (t *table) describe(status dynamodb.TableStatus) dynamodb.TableDescription {
+	var size int64
+	for _, item := range t.items {
+		size += itemSize(item)
+	}
+	td := dynamodb.TableDescription{
+		AttributeDefinitions: t.attrs,
+		CreationDateTime:     epoch(t.created),
+		ItemCount:            int64(len(t.items)),
+		KeySchema:            t.keySchema(t.hash, t.rng),
+		ProvisionedThroughput: dynamodb.ProvisionedThroughputDescription{
+			ReadCapacityUnits:  t.throughput.ReadCapacityUnits,
+			WriteCapacityUnits: t.throughput.WriteCapacityUnits,
+		},
+		TableArn:       t.arn(),
+		TableName:      t.name,
+		TableSizeBytes: size,
+		TableStatus:    status,
+	}
+	for _, idx := range t.indexes {
+		var count, size int64
+		for _, item := range t.items {
+			if inIndex(idx, item) {
+				count++
+				size += itemSize(project(item, func(name string) bool { return idx.projects(t, name) }))
+			}
+		}
+		if idx.global {
+			td.GlobalSecondaryIndexes = append(td.GlobalSecondaryIndexes, dynamodb.GlobalSecondaryIndexDescription{
+				IndexName:      idx.name,
+				IndexSizeBytes: size,
+				IndexStatus:    dynamodb.IndexStatusActive,
+				ItemCount:      count,
+				KeySchema:      t.keySchema(idx.hash, idx.rng),
+				Projection:     idx.projection,
+				ProvisionedThroughput: dynamodb.ProvisionedThroughputDescription{
+					ReadCapacityUnits:  idx.throughput.ReadCapacityUnits,
+					WriteCapacityUnits: idx.throughput.WriteCapacityUnits,
+				},
+			})
+		} else {
+			td.LocalSecondaryIndexes = append(td.LocalSecondaryIndexes, dynamodb.LocalSecondaryIndexDescription{
+				IndexName:      idx.name,
+				IndexSizeBytes: size,
+				ItemCount:      count,
+				KeySchema:      t.keySchema(idx.hash, idx.rng),
+				Projection:     idx.projection,
+			})
+		}
+	}
+	return td
+}
+
+// parseKeySchema returns the hash and the range key in ks.
+func parseKeySchema(ks []dynamodb.KeySchemaElement, defined map[string]bool) (string, string, error) {
+	if len(ks) == 0 || len(ks) > 2 {
+		return "", "", validationError("1 validation error detected: Value '%v' at 'keySchema' failed to satisfy constraint: Member must have length less than or equal to 2", ks)
+	}
+	if ks[0].KeyType != dynamodb.KeyTypeHash {
+		return "", "", validationError("Invalid KeySchema: The first KeySchemaElement is not a HASH key type")
+	}
+	var rng string
+	if len(ks) == 2 {
+		if ks[1].KeyType != dynamodb.KeyTypeRange {
+			return "", "", validationError("Invalid KeySchema: The second KeySchemaElement is not a RANGE key type")
+		}
+		rng = ks[1].AttributeName
+		if rng == ks[0].AttributeName {
+			return "", "", validationError("Both the Hash Key and the Range Key element in the KeySchema have the same name")
+		}
+	}
+	for _, e := range ks {
+		if !defined[e.AttributeName] {
+			return "", "", validationError("One or more parameter values were invalid: Some index key attributes are not defined in AttributeDefinitions. Keys: [%s]", e.AttributeName)
+		}
+	}
+	return ks[0].AttributeName, rng, nil
+}
+
+func validateProjection(p dynamodb.Projection) error {
+	switch p.ProjectionType {
+	case dynamodb.ProjectionTypeKeysOnly, dynamodb.ProjectionTypeAll:
+		if len(p.NonKeyAttributes) > 0 {
+			return validationError("One or more parameter values were invalid: ProjectionType is %s, but NonKeyAttributes is specified", p.ProjectionType)
+		}
+	case dynamodb.ProjectionTypeInclude:
+		if len(p.NonKeyAttributes) == 0 {
+			return validationError("One or more parameter values were invalid: ProjectionType is INCLUDE, but NonKeyAttributes is not specified")
+		}
+	default:
+		return validationError("One or more parameter values were invalid: Unknown ProjectionType: %s", p.ProjectionType)
+	}
+	return nil
+}
+
+func validateThroughput(pt dynamodb.ProvisionedThroughput) error {
+	if pt.ReadCapacityUnits < 1 || pt.WriteCapacityUnits < 1 {
+		return validationError("One or more parameter values were invalid: Provisioned throughput must be greater than or equal to 1")
+	}
+	return nil
+}
+
+type createTableInput struct {
+	TableName              string
+	AttributeDefinitions   []dynamodb.AttributeDefinition
+	KeySchema              []dynamodb.KeySchemaElement
+	ProvisionedThroughput  dynamodb.ProvisionedThroughput
+	GlobalSecondaryIndexes []dynamodb.GlobalSecondaryIndex
+	LocalSecondaryIndexes  []dynamodb.LocalSecondaryIndex
+	Tags                   []dynamodb.Tag
+}
+
+func (h *Handler) createTable(body []byte) (interface{}, error) {
+	var in createTableInput
+	if err := decode(body, &in); err != nil {
+		return nil, err
+	}
+	if !tableNamePattern.MatchString(in.TableName) {
+		return nil, validationError("TableName must be at least 3 characters long and at most 255 characters long, and may contain only a-z, A-Z, 0-9, '_', '-' and '.'")
+	}
+	if _, ok := h.tables[in.TableName]; ok {
+		return nil, newError(dynamodb.CodeResourceInUse, "Cannot create preexisting table")
+	}
+
+	defined := map[string]bool{}
+	for _, ad := range in.AttributeDefinitions {
+		switch ad.Type {
+		case dynamodb.TypeString, dynamodb.TypeNumber, dynamodb.TypeBinary:
+		default:
+			return nil, validationError("1 validation error detected: Value '%s' at 'attributeDefinitions.member.attributeType' failed to satisfy constraint: Member must satisfy enum value set: [B, N, S]", ad.Type)
+		}
+		if defined[ad.Name] {
+			return nil, validationError("Cannot have two attributes with the same name: %s", ad.Name)
+		}
+		defined[ad.Name] = true
+	}
+
+	t := &table{
+		name:       in.TableName,
+		created:    time.Now(),
+		attrs:      in.AttributeDefinitions,
+		throughput: in.ProvisionedThroughput,
+		tags:       in.Tags,
+		items:      map[string]dynamodb.Item{},
+	}
+	var err error
+	t.hash, t.rng, err = parseKeySchema(in.KeySchema, defined)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateThroughput(in.ProvisionedThroughput); err != nil {
+		return nil, err
+	}
+
+	used := map[string]bool{t.hash: true, t.rng: true}
+	names := map[string]bool{}
+	for _, lsi := range in.LocalSecondaryIndexes {
+		idx := &index{name: lsi.IndexName, projection: lsi.Projection}
+		if idx.hash, idx.rng, err = parseKeySchema(lsi.KeySchema, defined); err != nil {
+			return nil, err
+		}
+		if t.rng == "" {
+			return nil, validationError("One or more parameter values were invalid: Table KeySchema does not have a range key, which is required when specifying a LocalSecondaryIndex")
+		}
+		if idx.hash != t.hash || idx.rng == "" {
+			return nil, validationError("One or more parameter values were invalid: Index KeySchema does not have the same leading hash key as table KeySchema for index: %s", idx.name)
+		}
+		t.indexes = append(t.indexes, idx)
+	}
+	for _, gsi := range in.GlobalSecondaryIndexes {
+		idx := &index{name: gsi.IndexName, global: true, projection: gsi.Projection, throughput: gsi.ProvisionedThroughput}
+		if idx.hash, idx.rng, err = parseKeySchema(gsi.KeySchema, defined); err != nil {
+			return nil, err
+		}
+		if err := validateThroughput(gsi.ProvisionedThroughput); err != nil {
+			return nil, err
+		}
+		t.indexes = append(t.indexes, idx)
+	}
+	for _, idx := range t.indexes {
+		if names[idx.name] {
+			return nil, validationError("One or more parameter values were invalid: Duplicate index name: %s", idx.name)
+		}
+		names[idx.name] = true
+		if err := validateProjection(idx.projection); err != nil {
+			return nil, err
+		}
+		used[idx.hash], used[idx.rng] = true, true
+	}
+	for name := range defined {
+		if !used[name] {
+			return nil, validationError("One or more parameter values were invalid: Number of attributes in KeySchema does not exactly match number of attributes defined in AttributeDefinitions")
+		}
+	}
+
+	h.tables[t.name] = t
+	return map[string]interface{}{"TableDescription": t.describe(dynamodb.TableStatusActive)}, nil
+}
+
+type tableNameInput struct {
+	TableName string
+}
+
+func (h *Handler) describeTable(body []byte) (interface{}, error) {
+	var in tableNameInput
+	if err := decode(body, &in); err != nil {
+		return nil, err
+	}
+	t, err := h.lookup(in.TableName)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"Table": t.describe(dynamodb.TableStatusActive)}, nil
+}
+
+func (h *Handler) deleteTable(body []byte) (interface{}, error) {
+	var in tableNameInput
+	if err := decode(body, &in); err != nil {
+		return nil, err
+	}
+	t, err := h.lookup(in.TableName)
+	if err != nil {
+		return nil, err
+	}
+	delete(h.tables, t.name)
+	return map[string]interface{}{"TableDescription": t.describe(dynamodb.TableStatusDeleting)}, nil
+}
+
+type listTablesInput struct {
+	ExclusiveStartTableName string
+	Limit                   int
+}
+
+func (h *Handler) listTables(body []byte) (interface{}, error) {
+	var in listTablesInput
+	if err := decode(body, &in); err != nil {
+		return nil, err
+	}
+	if in.Limit < 0 || in.Limit > maxListTables {
+		return nil, validationError("1 validation error detected: Value '%d' at 'limit' failed to satisfy constraint: Member must have value less than or equal to %d", in.Limit, maxListTables)
+	}
+	if in.Limit == 0 {
+		in.Limit = maxListTables
+	}
+
+	names := []string{}
+	for name := range h.tables {
+		if name > in.ExclusiveStartTableName {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	ret := map[string]interface{}{}
+	if len(names) > in.Limit {
+		names = names[:in.Limit]
+		ret["LastEvaluatedTableName"] = names[len(names)-1]
+	}
+	ret["TableNames"] = names
+	return ret, nil
+}
+
+type updateTableInput struct {
+	TableName                   string
+	GlobalSecondaryIndexUpdates []dynamodb.GlobalSecondaryIndexUpdate
+	ProvisionedThroughput       dynamodb.ProvisionedThroughput
+}
+
+func (h *Handler) updateTable(body []byte) (interface{}, error) {
+	var in updateTableInput
+	if err := decode(body, &in); err != nil {
+		return nil, err
+	}
+	t, err := h.lookup(in.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	// the client always sends ProvisionedThroughput so zero means no update
+	updateTable := in.ProvisionedThroughput != dynamodb.ProvisionedThroughput{}
+	if !updateTable && len(in.GlobalSecondaryIndexUpdates) == 0 {
+		return nil, validationError("At least one of ProvisionedThroughput, BillingMode, UpdateStreamEnabled, GlobalSecondaryIndexUpdates or SSESpecification or ReplicaUpdates is required")
+	}
+	if updateTable {
+		if err := validateThroughput(in.ProvisionedThroughput); err != nil {
+			return nil, err
+		}
+	}
+	updates := map[*index]dynamodb.ProvisionedThroughput{}
+	for _, u := range in.GlobalSecondaryIndexUpdates {
+		idx, err := t.index(u.Update.IndexName)
+		if err != nil || !idx.global {
+			return nil, newError(dynamodb.CodeResourceNotFound, "Requested resource not found: Index: %s not found", u.Update.IndexName)
+		}
+		if err := validateThroughput(u.Update.ProvisionedThroughput); err != nil {
+			return nil, err
+		}
+		updates[idx] = u.Update.ProvisionedThroughput
+	}
+
+	if updateTable {
+		t.throughput = in.ProvisionedThroughput
+	}
+	for idx, pt := range updates {
+		idx.throughput = pt
+	}
+	return map[string]interface{}{"TableDescription": t.describe(dynamodb.TableStatusActive)}, nil
+}
+
+func (h *Handler) describeLimits(body []byte) (interface{}, error) {
+	return dynamodb.DescribeLimitsResult{
+		AccountMaxReadCapacityUnits:  80000,
+		AccountMaxWriteCapacityUnits: 80000,
+		TableMaxReadCapacityUnits:    40000,
+		TableMaxWriteCapacityUnits:   40000,
+	}, nil
+}
+
+// lookupARN returns the table identified by arn.
+func (h *Handler) lookupARN(arn string) (*table, error) {
+	if t, ok := h.tables[strings.TrimPrefix(arn, arnPrefix)]; ok && strings.HasPrefix(arn, arnPrefix) {
+		return t, nil
+	}
+	return nil, newError(dynamodb.CodeResourceNotFound, "Requested resource not found: ResourcArn: %s not found", arn)
+}
+
+type tagResourceInput struct {
+	ResourceArn string
+	Tags        []dynamodb.Tag
+	TagKeys     []string
+}
+
+func (h *Handler) tagResource(body []byte) (interface{}, error) {
+	var in tagResourceInput
+	if err := decode(body, &in); err != nil {
+		return nil, err
+	}
+	t, err := h.lookupARN(in.ResourceArn)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range in.Tags {
+		t.untag(tag.Key)
+		t.tags = append(t.tags, tag)
+	}
+	return struct{}{}, nil
+}
+
+func (h *Handler) untagResource(body []byte) (interface{}, error) {
+	var in tagResourceInput
+	if err := decode(body, &in); err != nil {
+		return nil, err
+	}
+	t, err := h.lookupARN(in.ResourceArn)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range in.TagKeys {
+		t.untag(key)
+	}
+	return struct{}{}, nil
+}
+
+func (t *table) untag(key string) {
+	tags := t.tags[:0]
+	for _, tag := range t.tags {
+		if tag.Key != key {
+			tags = append(tags, tag)
+		}
+	}
+	t.tags = tags
+}
+
+func (h *Handler) listTagsOfResource(body []byte) (interface{}, error) {
+	var in tagResourceInput
+	if err := decode(body, &in); err != nil {
+		return nil, err
+	}
+	t, err := h.lookupARN(in.ResourceArn)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"Tags": t.tags}, nil
+}
@@ -0,0 +1,35 @@
+package dynamodbtest
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/nabeken/goamz-dynamodb"
+)
+
+// formatNumber formats r in the shortest decimal notation.
+func formatNumber(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+	scaled := new(big.Rat).Set(r)
+	ten := big.NewRat(10, 1)
+	for prec := 1; prec <= 38; prec++ {
+		scaled.Mul(scaled, ten)
+		if scaled.IsInt() {
+			return r.FloatString(prec)
+		}
+	}
+	return strings.TrimRight(r.FloatString(38), "0")
+}
+
+// setContains reports whether set has d. Stored values are normalized,
+// so equal numbers and binaries have the same data.
+func setContains(set dynamodb.AttributeValue, d dynamodb.AttributeData) bool {
+	for _, e := range set.Data {
+		if e == d {
+			return true
+		}
+	}
+	return false
+}
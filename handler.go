@@ -0,0 +1,149 @@
+package dynamodb
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+)
+
+// Request carries a single attempt of an operation through the stages of Client.Do.
+type Request struct {
+	Context context.Context
+
+	// Operation is the name of the operation such as "PutItem".
+	Operation string
+	// Param is the parameter of the operation given to Client.Do.
+	Param interface{}
+	// Body is the JSON-encoded Param. Build middleware may replace it before calling next.
+	Body []byte
+	// Attempt is the number of the attempt starting at 0.
+	Attempt int
+
+	// HTTPRequest is set by the build stage.
+	HTTPRequest *http.Request
+	// HTTPResponse is set by the send stage. Its body is already read and closed.
+	HTTPResponse *http.Response
+	// ResponseBody is set by the send stage.
+	ResponseBody []byte
+}
+
+// Handler processes Request in a stage.
+type Handler func(r *Request) error
+
+// Middleware wraps Handler of a stage. It can modify Request before
+// and after calling next, or return without calling next.
+type Middleware func(next Handler) Handler
+
+// Handlers holds middlewares for each stage of an attempt in Client.Do.
+// The stages run in the order of Build, Sign, Send and Unmarshal.
+// The first middleware in a stage is the outermost.
+type Handlers struct {
+	// Build builds HTTPRequest from Body.
+	Build []Middleware
+	// Sign adds X-Amz-Date and credentials to HTTPRequest and signs it.
+	Sign []Middleware
+	// Send sends HTTPRequest and reads HTTPResponse into ResponseBody.
+	// Replacing it with a middleware which does not call next replaces the transport.
+	Send []Middleware
+	// Unmarshal converts an error response into *Error.
+	// The successful ResponseBody is decoded later by Response.Scan.
+	Unmarshal []Middleware
+}
+
+func chain(ms []Middleware, h Handler) Handler {
+	for i := len(ms) - 1; i >= 0; i-- {
+		h = ms[i](h)
+	}
+	return h
+}
+
+// attempt runs the stages for r.
+func (c *Client) attempt(r *Request) error {
+	stages := []struct {
+		middlewares []Middleware
+		handler     Handler
+	}{
+		{c.Handlers.Build, c.build},
+		{c.Handlers.Sign, c.sign},
+		{c.Handlers.Send, c.sendHTTP},
+		{c.Handlers.Unmarshal, unmarshalError},
+	}
+	for _, s := range stages {
+		if err := chain(s.middlewares, s.handler)(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// build builds an HTTP request with a fresh body.
+func (c *Client) build(r *Request) error {
+	hreq, err := http.NewRequest("POST", c.Region.DynamoDBEndpoint+"/", bytes.NewReader(r.Body))
+	if err != nil {
+		return err
+	}
+	hreq.Header.Set("Content-Type", "application/x-amz-json-1.0")
+	hreq.Header.Set("X-Amz-Target", target(r.Operation))
+	r.HTTPRequest = hreq.WithContext(r.Context)
+	return nil
+}
+
+// sign signs the HTTP request with a fresh X-Amz-Date.
+func (c *Client) sign(r *Request) error {
+	creds := Credentials{
+		AccessKeyID:     c.Auth.AccessKey,
+		SecretAccessKey: c.Auth.SecretKey,
+		SessionToken:    c.Auth.Token,
+	}
+	if c.Credentials != nil {
+		var err error
+		creds, err = c.Credentials.Retrieve(r.Context)
+		if err != nil {
+			return err
+		}
+	}
+
+	signer := &Signer{
+		Credentials: creds,
+		Region:      c.Region.signingName(),
+		Service:     "dynamodb",
+	}
+	signer.Sign(r.HTTPRequest, r.Body, c.now())
+	return nil
+}
+
+// sendHTTP sends the HTTP request. The response body is closed before it returns.
+func (c *Client) sendHTTP(r *Request) error {
+	resp, err := c.HTTPClient.Do(r.HTTPRequest)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	r.HTTPResponse = resp
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return ErrFailedtoReadResponse
+	}
+	r.ResponseBody = body
+
+	// The checksum is calculated on the compressed body, which gzip verifies by itself.
+	// Errors may come from proxies without the header, so only successful responses are verified.
+	if !c.DisableCRC32Check && !resp.Uncompressed && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if err := verifyCRC32(resp, body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unmarshalError(r *Request) error {
+	if r.HTTPResponse == nil {
+		return ErrNoResponse
+	}
+	if r.HTTPResponse.StatusCode != 200 {
+		return NewError(r.HTTPResponse, r.ResponseBody)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package dynamodb_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nabeken/goamz-dynamodb"
+)
+
+func TestHandlers(t *testing.T) {
+	var header http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		w.Write([]byte(`{"TableNames":["TABLE"]}`))
+	}))
+	defer ts.Close()
+
+	var stages []string
+	record := func(name string) dynamodb.Middleware {
+		return func(next dynamodb.Handler) dynamodb.Handler {
+			return func(r *dynamodb.Request) error {
+				stages = append(stages, name+":before")
+				err := next(r)
+				stages = append(stages, name+":after")
+				return err
+			}
+		}
+	}
+
+	c := &dynamodb.Client{
+		Auth:              dummyAuth,
+		Region:            dynamodb.Region{DynamoDBEndpoint: ts.URL},
+		DisableCRC32Check: true,
+		Handlers: dynamodb.Handlers{
+			Build: []dynamodb.Middleware{
+				record("build1"),
+				record("build2"),
+				func(next dynamodb.Handler) dynamodb.Handler {
+					return func(r *dynamodb.Request) error {
+						if err := next(r); err != nil {
+							return err
+						}
+						r.HTTPRequest.Header.Set("X-Custom-Header", r.Operation)
+						return nil
+					}
+				},
+			},
+			Sign:      []dynamodb.Middleware{record("sign")},
+			Send:      []dynamodb.Middleware{record("send")},
+			Unmarshal: []dynamodb.Middleware{record("unmarshal")},
+		},
+	}
+	ret, err := c.ListTables(nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"TABLE"}, ret.TableNames)
+	}
+	assert.Equal(t, []string{
+		"build1:before", "build2:before", "build2:after", "build1:after",
+		"sign:before", "sign:after",
+		"send:before", "send:after",
+		"unmarshal:before", "unmarshal:after",
+	}, stages)
+
+	// the header added in the build stage is signed
+	assert.Equal(t, "ListTables", header.Get("X-Custom-Header"))
+	assert.Contains(t, header.Get("Authorization"), "x-custom-header")
+}
+
+func TestHandlers_ReplaceTransport(t *testing.T) {
+	c := &dynamodb.Client{
+		Auth:   dummyAuth,
+		Region: dynamodb.Region{DynamoDBEndpoint: "http://127.0.0.1:1"},
+		Handlers: dynamodb.Handlers{
+			Send: []dynamodb.Middleware{
+				func(next dynamodb.Handler) dynamodb.Handler {
+					return func(r *dynamodb.Request) error {
+						body, _ := ioutil.ReadAll(r.HTTPRequest.Body)
+						if !strings.Contains(string(body), `"Limit":1`) {
+							return errors.New("unexpected body")
+						}
+						r.HTTPResponse = &http.Response{StatusCode: 200, Status: "200 OK", Header: http.Header{}}
+						r.ResponseBody = []byte(`{"TableNames":["MOCK"]}`)
+						return nil
+					}
+				},
+			},
+			Unmarshal: []dynamodb.Middleware{
+				func(next dynamodb.Handler) dynamodb.Handler {
+					return func(r *dynamodb.Request) error {
+						r.ResponseBody = bytes.Replace(r.ResponseBody, []byte("MOCK"), []byte("MODIFIED"), 1)
+						return next(r)
+					}
+				},
+			},
+		},
+	}
+	ret, err := c.ListTables(&dynamodb.ListTablesOption{Limit: 1})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"MODIFIED"}, ret.TableNames)
+	}
+}
+
+func TestHandlers_NoResponse(t *testing.T) {
+	c := &dynamodb.Client{
+		Auth:    dummyAuth,
+		Region:  dynamodb.Region{DynamoDBEndpoint: "http://127.0.0.1:1"},
+		Retryer: dynamodb.NoOpRetryer{},
+		Handlers: dynamodb.Handlers{
+			Send: []dynamodb.Middleware{
+				func(next dynamodb.Handler) dynamodb.Handler {
+					return func(r *dynamodb.Request) error {
+						// forgets to set HTTPResponse
+						r.ResponseBody = []byte(`{}`)
+						return nil
+					}
+				},
+			},
+		},
+	}
+	_, err := c.ListTables(nil)
+	assert.Equal(t, dynamodb.ErrNoResponse, err)
+}
+
+func TestHandlers_Abort(t *testing.T) {
+	errAbort := errors.New("abort")
+	var sent bool
+	c := &dynamodb.Client{
+		Auth:    dummyAuth,
+		Region:  dynamodb.Region{DynamoDBEndpoint: "http://127.0.0.1:1"},
+		Retryer: dynamodb.NoOpRetryer{},
+		Handlers: dynamodb.Handlers{
+			Sign: []dynamodb.Middleware{
+				func(next dynamodb.Handler) dynamodb.Handler {
+					return func(r *dynamodb.Request) error {
+						return errAbort
+					}
+				},
+			},
+			Send: []dynamodb.Middleware{
+				func(next dynamodb.Handler) dynamodb.Handler {
+					return func(r *dynamodb.Request) error {
+						sent = true
+						return next(r)
+					}
+				},
+			},
+		},
+	}
+	_, err := c.ListTables(nil)
+	assert.Equal(t, errAbort, err)
+	assert.False(t, sent)
+}
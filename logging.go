@@ -0,0 +1,182 @@
+package dynamodb
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LogLevel is the verbosity of Logger.
+type LogLevel int
+
+const (
+	// LogOff disables logging.
+	LogOff LogLevel = iota
+	// LogError logs failed operations.
+	LogError
+	// LogInfo logs every operation with its table, status, retry count and latency.
+	LogInfo
+	// LogDebug logs every attempt and its HTTP headers in addition to LogInfo.
+	LogDebug
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogOff:
+		return "OFF"
+	case LogError:
+		return "ERROR"
+	case LogInfo:
+		return "INFO"
+	case LogDebug:
+		return "DEBUG"
+	}
+	return fmt.Sprintf("LogLevel(%d)", int(l))
+}
+
+const redacted = "REDACTED"
+
+// Logger receives log messages from Client.
+type Logger interface {
+	Log(level LogLevel, msg string)
+}
+
+// LoggerFunc adapts a function to Logger.
+type LoggerFunc func(level LogLevel, msg string)
+
+func (f LoggerFunc) Log(level LogLevel, msg string) {
+	f(level, msg)
+}
+
+// NewStdLogger returns Logger which writes to l.
+func NewStdLogger(l *log.Logger) Logger {
+	return LoggerFunc(func(level LogLevel, msg string) {
+		l.Printf("[%s] %s", level, msg)
+	})
+}
+
+// LogConfig configures logging of Client.
+type LogConfig struct {
+	Level LogLevel
+
+	// Bodies logs request and response bodies at LogDebug.
+	Bodies bool
+
+	// RedactAttributes lists attribute names such as PII fields whose values
+	// are redacted in the logged bodies. Authorization and X-Amz-Security-Token
+	// headers are always redacted.
+	RedactAttributes []string
+}
+
+func (c *Client) logEnabled(level LogLevel) bool {
+	return c.Logger != nil && c.LogConfig.Level >= level
+}
+
+func (c *Client) logOperation(name string, tables []string, retries int, latency time.Duration, status int, err error) {
+	level := LogInfo
+	if err != nil {
+		level = LogError
+	}
+	if !c.logEnabled(level) {
+		return
+	}
+	msg := fmt.Sprintf("dynamodb: %s table=%s status=%d retries=%d latency=%s",
+		name, strings.Join(tables, ","), status, retries, latency)
+	if err != nil {
+		msg += " error=" + err.Error()
+	}
+	c.Logger.Log(level, msg)
+}
+
+func (c *Client) logAttempt(r *Request, latency time.Duration, err error) {
+	if !c.logEnabled(LogDebug) {
+		return
+	}
+	msg := fmt.Sprintf("dynamodb: %s attempt=%d status=%d latency=%s",
+		r.Operation, r.Attempt, statusCode(r), latency)
+	if err != nil {
+		msg += " error=" + err.Error()
+	}
+	if r.HTTPRequest != nil {
+		msg += "\nrequest headers: " + redactHeader(r.HTTPRequest.Header)
+	}
+	if c.LogConfig.Bodies {
+		msg += "\nrequest body: " + c.redactBody(r.Body)
+	}
+	if r.HTTPResponse != nil {
+		msg += "\nresponse headers: " + redactHeader(r.HTTPResponse.Header)
+	}
+	if c.LogConfig.Bodies && r.ResponseBody != nil {
+		msg += "\nresponse body: " + c.redactBody(r.ResponseBody)
+	}
+	c.Logger.Log(LogDebug, msg)
+}
+
+func statusCode(r *Request) int {
+	if r.HTTPResponse == nil {
+		return 0
+	}
+	return r.HTTPResponse.StatusCode
+}
+
+func redactHeader(h http.Header) string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := make([]string, 0, len(keys))
+	for _, k := range keys {
+		v := strings.Join(h[k], ",")
+		switch http.CanonicalHeaderKey(k) {
+		case "Authorization", "X-Amz-Security-Token":
+			v = redacted
+		}
+		fields = append(fields, k+"="+v)
+	}
+	return strings.Join(fields, " ")
+}
+
+// redactBody replaces values of RedactAttributes in the JSON-encoded body.
+// A body which is not JSON is logged as is.
+func (c *Client) redactBody(body []byte) string {
+	if len(c.LogConfig.RedactAttributes) == 0 {
+		return string(body)
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return string(body)
+	}
+	names := map[string]struct{}{}
+	for _, name := range c.LogConfig.RedactAttributes {
+		names[name] = struct{}{}
+	}
+	j, err := json.Marshal(redactValue(v, names))
+	if err != nil {
+		return string(body)
+	}
+	return string(j)
+}
+
+func redactValue(v interface{}, names map[string]struct{}) interface{} {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		for k := range vv {
+			if _, ok := names[k]; ok {
+				vv[k] = redacted
+				continue
+			}
+			vv[k] = redactValue(vv[k], names)
+		}
+	case []interface{}:
+		for i := range vv {
+			vv[i] = redactValue(vv[i], names)
+		}
+	}
+	return v
+}
@@ -0,0 +1,130 @@
+package dynamodb_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nabeken/goamz-dynamodb"
+)
+
+type logEntry struct {
+	level dynamodb.LogLevel
+	msg   string
+}
+
+func newLogTestClient(ts *httptest.Server, config dynamodb.LogConfig, entries *[]logEntry) *dynamodb.Client {
+	return &dynamodb.Client{
+		Auth:              dynamodb.Auth{AccessKey: "DUMMY_KEY", SecretKey: "DUMMY_SECRET", Token: "SECRET_TOKEN"},
+		Region:            dynamodb.Region{DynamoDBEndpoint: ts.URL},
+		DisableCRC32Check: true,
+		Retryer:           dynamodb.DefaultRetryer{MinRetryDelay: time.Millisecond, MaxRetryDelay: time.Millisecond},
+		Logger: dynamodb.LoggerFunc(func(level dynamodb.LogLevel, msg string) {
+			*entries = append(*entries, logEntry{level, msg})
+		}),
+		LogConfig: config,
+	}
+}
+
+func TestLogger_Info(t *testing.T) {
+	var n int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		if n == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"__type":"InternalServerError","message":"oops"}`))
+			return
+		}
+		w.Write([]byte(`{"Item":{"ID":{"S":"1"}}}`))
+	}))
+	defer ts.Close()
+
+	var entries []logEntry
+	c := newLogTestClient(ts, dynamodb.LogConfig{Level: dynamodb.LogInfo}, &entries)
+
+	_, err := c.GetItem("TABLE", dynamodb.Item{"ID": dynamodb.NewString("1")}, nil)
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, dynamodb.LogInfo, entries[0].level)
+		assert.Contains(t, entries[0].msg, "GetItem table=TABLE status=200 retries=1 latency=")
+	}
+}
+
+func TestLogger_Error(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") == "DynamoDB_20120810.ListTables" {
+			w.Write([]byte(`{"TableNames":[]}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#ResourceNotFoundException","message":"not found"}`))
+	}))
+	defer ts.Close()
+
+	var entries []logEntry
+	c := newLogTestClient(ts, dynamodb.LogConfig{Level: dynamodb.LogError}, &entries)
+
+	_, err := c.ListTables(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	_, err = c.DescribeTable("TABLE")
+	assert.Error(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, dynamodb.LogError, entries[0].level)
+		assert.Contains(t, entries[0].msg, "DescribeTable table=TABLE status=400 retries=0")
+		assert.Contains(t, entries[0].msg, "error=dynamodb: ResourceNotFoundException: not found")
+	}
+}
+
+func TestLogger_DebugRedaction(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Item":{"ID":{"S":"1"},"SSN":{"S":"123-45-6789"}}}`))
+	}))
+	defer ts.Close()
+
+	var entries []logEntry
+	c := newLogTestClient(ts, dynamodb.LogConfig{
+		Level:            dynamodb.LogDebug,
+		Bodies:           true,
+		RedactAttributes: []string{"SSN"},
+	}, &entries)
+
+	_, err := c.PutItem("TABLE", dynamodb.Item{
+		"ID":  dynamodb.NewString("1"),
+		"SSN": dynamodb.NewString("123-45-6789"),
+	}, nil)
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 2) {
+		debug := entries[0]
+		assert.Equal(t, dynamodb.LogDebug, debug.level)
+		assert.Contains(t, debug.msg, "PutItem attempt=0 status=200")
+		assert.Contains(t, debug.msg, "Authorization=REDACTED")
+		assert.Contains(t, debug.msg, "X-Amz-Security-Token=REDACTED")
+		assert.Contains(t, debug.msg, "X-Amz-Target=DynamoDB_20120810.PutItem")
+		assert.Contains(t, debug.msg, `"SSN":"REDACTED"`)
+		assert.Contains(t, debug.msg, `"ID":{"S":"1"}`)
+		assert.NotContains(t, debug.msg, "123-45-6789")
+		assert.NotContains(t, debug.msg, "SECRET_TOKEN")
+		assert.Equal(t, 2, strings.Count(debug.msg, `"SSN":"REDACTED"`))
+
+		assert.Equal(t, dynamodb.LogInfo, entries[1].level)
+	}
+}
+
+func TestLogger_Off(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"TableNames":[]}`))
+	}))
+	defer ts.Close()
+
+	var entries []logEntry
+	c := newLogTestClient(ts, dynamodb.LogConfig{}, &entries)
+	_, err := c.ListTables(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,73 @@
+package dynamodb
+
+import (
+	"strings"
+	"time"
+)
+
+// Metric names reported to Metrics.
+const (
+	// MetricRequests counts operations by operation and table.
+	MetricRequests = "dynamodb_requests_total"
+	// MetricErrors counts failed operations by operation, table and code.
+	MetricErrors = "dynamodb_errors_total"
+	// MetricRetries counts retried attempts by operation and table.
+	MetricRetries = "dynamodb_retries_total"
+	// MetricLatency observes the latency of operations in seconds including retries.
+	MetricLatency = "dynamodb_request_duration_seconds"
+	// MetricConsumedCapacity counts consumed capacity units by operation and table.
+	MetricConsumedCapacity = "dynamodb_consumed_capacity_units_total"
+)
+
+// Label names attached to the metrics.
+const (
+	LabelOperation = "operation"
+	LabelTable     = "table"
+	LabelCode      = "code"
+)
+
+// Labels is a set of label names and values.
+type Labels map[string]string
+
+// Metrics receives metrics from Client.
+type Metrics interface {
+	// AddCounter adds delta to the counter.
+	AddCounter(name string, labels Labels, delta float64)
+	// ObserveHistogram records value into the histogram.
+	ObserveHistogram(name string, labels Labels, value float64)
+}
+
+func (c *Client) reportMetrics(name string, tables []string, retries int, latency time.Duration, body []byte, err error) {
+	labels := Labels{
+		LabelOperation: name,
+		LabelTable:     strings.Join(tables, ","),
+	}
+	c.Metrics.AddCounter(MetricRequests, labels, 1)
+	if retries > 0 {
+		c.Metrics.AddCounter(MetricRetries, labels, float64(retries))
+	}
+	c.Metrics.ObserveHistogram(MetricLatency, labels, latency.Seconds())
+
+	if err != nil {
+		code := ErrorCode(err)
+		if code == "" {
+			code = "Unknown"
+		}
+		c.Metrics.AddCounter(MetricErrors, Labels{
+			LabelOperation: name,
+			LabelTable:     labels[LabelTable],
+			LabelCode:      code,
+		}, 1)
+		return
+	}
+
+	for _, cc := range consumedCapacities(body) {
+		if cc.CapacityUnits == 0 {
+			continue
+		}
+		c.Metrics.AddCounter(MetricConsumedCapacity, Labels{
+			LabelOperation: name,
+			LabelTable:     cc.TableName,
+		}, cc.CapacityUnits)
+	}
+}
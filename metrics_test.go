@@ -0,0 +1,97 @@
+package dynamodb_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nabeken/goamz-dynamodb"
+)
+
+func TestMetrics_Prometheus(t *testing.T) {
+	var n int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		switch n {
+		case 1:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#ProvisionedThroughputExceededException","message":"slow down"}`))
+		case 2:
+			w.Write([]byte(`{"Item":{"ID":{"S":"1"}},"ConsumedCapacity":{"TableName":"TABLE","CapacityUnits":0.5}}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#ConditionalCheckFailedException","message":"failed"}`))
+		}
+	}))
+	defer ts.Close()
+
+	m := dynamodb.NewPrometheusMetrics(0.5, 10)
+	c := &dynamodb.Client{
+		Auth:              dummyAuth,
+		Region:            dynamodb.Region{DynamoDBEndpoint: ts.URL},
+		DisableCRC32Check: true,
+		Retryer: dynamodb.DefaultRetryer{
+			MinThrottleDelay: time.Millisecond,
+			MaxThrottleDelay: time.Millisecond,
+		},
+		Metrics: m,
+	}
+
+	_, err := c.GetItem("TABLE", dynamodb.Item{"ID": dynamodb.NewString("1")}, &dynamodb.GetItemOption{ReturnConsumedCapacity: "TOTAL"})
+	assert.NoError(t, err)
+	_, err = c.DeleteItem("TABLE", dynamodb.Item{"ID": dynamodb.NewString("1")}, nil)
+	assert.Error(t, err)
+
+	ms := httptest.NewServer(m)
+	defer ms.Close()
+	resp, err := http.Get(ms.URL)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/plain")
+
+	for _, line := range []string{
+		`# TYPE dynamodb_requests_total counter`,
+		`dynamodb_requests_total{operation="GetItem",table="TABLE"} 1`,
+		`dynamodb_requests_total{operation="DeleteItem",table="TABLE"} 1`,
+		`dynamodb_retries_total{operation="GetItem",table="TABLE"} 1`,
+		`dynamodb_errors_total{code="ConditionalCheckFailedException",operation="DeleteItem",table="TABLE"} 1`,
+		`dynamodb_consumed_capacity_units_total{operation="GetItem",table="TABLE"} 0.5`,
+		`# TYPE dynamodb_request_duration_seconds histogram`,
+		`dynamodb_request_duration_seconds_bucket{operation="GetItem",table="TABLE",le="10"} 1`,
+		`dynamodb_request_duration_seconds_bucket{operation="GetItem",table="TABLE",le="+Inf"} 1`,
+		`dynamodb_request_duration_seconds_count{operation="DeleteItem",table="TABLE"} 1`,
+	} {
+		assert.Contains(t, string(body), line+"\n")
+	}
+}
+
+func TestPrometheusMetrics_ZeroValue(t *testing.T) {
+	var m dynamodb.PrometheusMetrics
+	m.AddCounter("c", dynamodb.Labels{"table": "TABLE"}, 1)
+	m.ObserveHistogram("h", nil, 0.3)
+
+	var sb strings.Builder
+	_, err := m.WriteTo(&sb)
+	assert.NoError(t, err)
+	assert.Contains(t, sb.String(), "c{table=\"TABLE\"} 1\n")
+	assert.Contains(t, sb.String(), "h_bucket{le=\"0.25\"} 0\nh_bucket{le=\"0.5\"} 1\n")
+	assert.Contains(t, sb.String(), "h_count 1\n")
+}
+
+func TestPrometheusMetrics_Escape(t *testing.T) {
+	m := dynamodb.NewPrometheusMetrics()
+	m.AddCounter("c", dynamodb.Labels{"table": "a\"b\\c\nd"}, 2)
+	m.AddCounter("c", dynamodb.Labels{"table": "a\"b\\c\nd"}, 3)
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, "# TYPE c counter\nc{table=\"a\\\"b\\\\c\\nd\"} 5\n", rec.Body.String())
+}
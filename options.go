@@ -0,0 +1,192 @@
+package dynamodb
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Environment variables read by NewClient.
+const (
+	EnvEndpoint          = "DYNAMODB_ENDPOINT"
+	EnvDisableCRC32Check = "DYNAMODB_DISABLE_CRC32_CHECK"
+	EnvRegion            = "AWS_REGION"
+	EnvDefaultRegion     = "AWS_DEFAULT_REGION"
+)
+
+var (
+	ErrNoRegion   = errors.New("dynamodb: region or endpoint is required")
+	ErrHTTPClient = errors.New("dynamodb: HTTP client must not be nil")
+)
+
+type options struct {
+	region   string
+	endpoint string
+	client   *Client
+
+	// the timeouts are applied after all options so that WithHTTPClient doesn't drop them
+	timeout        time.Duration
+	connectTimeout time.Duration
+}
+
+// Option configures Client built by NewClient.
+type Option func(*options) error
+
+// NewClient returns Client configured by the environment variables and opts.
+// The region is read from AWS_REGION or AWS_DEFAULT_REGION and the endpoint from DYNAMODB_ENDPOINT,
+// which overrides the endpoint of the region to target DynamoDB Local or dynalite.
+// DYNAMODB_DISABLE_CRC32_CHECK=true disables the CRC32 check.
+// opts take precedence over the environment variables.
+// Credentials default to NewDefaultCredentials.
+func NewClient(opts ...Option) (*Client, error) {
+	o := &options{
+		region:   getenv(EnvRegion, EnvDefaultRegion),
+		endpoint: os.Getenv(EnvEndpoint),
+		client:   &Client{},
+	}
+	if v := os.Getenv(EnvDisableCRC32Check); v != "" {
+		disable, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("dynamodb: invalid %s '%s'", EnvDisableCRC32Check, v)
+		}
+		o.client.DisableCRC32Check = disable
+	}
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
+	if err := o.applyTimeouts(); err != nil {
+		return nil, err
+	}
+
+	if o.region == "" && o.endpoint == "" {
+		return nil, ErrNoRegion
+	}
+	c := o.client
+	if o.region != "" {
+		r, ok := Regions[o.region]
+		if !ok {
+			r = NewRegion(o.region)
+		}
+		c.Region = r
+	}
+	if o.endpoint != "" {
+		u, err := url.Parse(o.endpoint)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("dynamodb: invalid endpoint '%s'", o.endpoint)
+		}
+		c.Region.DynamoDBEndpoint = o.endpoint
+	}
+	if c.Credentials == nil && c.Auth == (Auth{}) {
+		c.Credentials = NewDefaultCredentials()
+	}
+	return c, nil
+}
+
+// WithRegion sets the region by name such as "us-east-1".
+func WithRegion(name string) Option {
+	return func(o *options) error {
+		o.region = name
+		return nil
+	}
+}
+
+// WithEndpoint sets the endpoint URL such as "http://127.0.0.1:8000" which overrides the endpoint of the region.
+func WithEndpoint(endpoint string) Option {
+	return func(o *options) error {
+		o.endpoint = endpoint
+		return nil
+	}
+}
+
+// WithCredentials sets the credentials provider.
+func WithCredentials(p CredentialsProvider) Option {
+	return func(o *options) error {
+		o.client.Credentials = p
+		return nil
+	}
+}
+
+// WithHTTPClient sets the HTTP client. The client is copied and not modified by the other options.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(o *options) error {
+		if hc == nil {
+			return ErrHTTPClient
+		}
+		o.client.HTTPClient = *hc
+		return nil
+	}
+}
+
+// WithDisableCRC32Check disables the CRC32 check of responses with x-amz-crc32 header.
+func WithDisableCRC32Check(disable bool) Option {
+	return func(o *options) error {
+		o.client.DisableCRC32Check = disable
+		return nil
+	}
+}
+
+// WithRetryer sets the retryer.
+func WithRetryer(r Retryer) Option {
+	return func(o *options) error {
+		o.client.Retryer = r
+		return nil
+	}
+}
+
+// WithLogger sets the logger and the log level.
+func WithLogger(l Logger, level LogLevel) Option {
+	return func(o *options) error {
+		o.client.Logger = l
+		o.client.LogConfig.Level = level
+		return nil
+	}
+}
+
+// WithTimeout sets the timeout of each attempt including reading the response.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) error {
+		o.timeout = d
+		return nil
+	}
+}
+
+// WithConnectTimeout sets the timeout to establish a connection.
+// It is supported only if the transport of the HTTP client is *http.Transport.
+func WithConnectTimeout(d time.Duration) Option {
+	return func(o *options) error {
+		o.connectTimeout = d
+		return nil
+	}
+}
+
+func (o *options) applyTimeouts() error {
+	hc := &o.client.HTTPClient
+	if o.timeout != 0 {
+		hc.Timeout = o.timeout
+	}
+	if o.connectTimeout == 0 {
+		return nil
+	}
+	rt := hc.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	t, ok := rt.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("dynamodb: connect timeout is not supported by %T", rt)
+	}
+	t = t.Clone()
+	t.DialContext = (&net.Dialer{
+		Timeout:   o.connectTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	hc.Transport = t
+	return nil
+}
@@ -0,0 +1,133 @@
+package dynamodb_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nabeken/goamz-dynamodb"
+)
+
+func TestNewClient_Env(t *testing.T) {
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	t.Setenv("DYNAMODB_ENDPOINT", "")
+	t.Setenv("DYNAMODB_DISABLE_CRC32_CHECK", "")
+
+	_, err := dynamodb.NewClient()
+	assert.Equal(t, dynamodb.ErrNoRegion, err)
+
+	t.Setenv("AWS_DEFAULT_REGION", "ap-northeast-1")
+	c, err := dynamodb.NewClient()
+	if assert.NoError(t, err) {
+		assert.Equal(t, dynamodb.APNortheast, c.Region)
+		assert.NotNil(t, c.Credentials)
+	}
+
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("DYNAMODB_ENDPOINT", "http://127.0.0.1:8000")
+	c, err = dynamodb.NewClient()
+	if assert.NoError(t, err) {
+		assert.Equal(t, dynamodb.Region{Name: "eu-west-1", DynamoDBEndpoint: "http://127.0.0.1:8000"}, c.Region)
+	}
+
+	// the options take precedence
+	c, err = dynamodb.NewClient(dynamodb.WithRegion("us-west-2"), dynamodb.WithEndpoint("http://127.0.0.1:4567"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, dynamodb.Region{Name: "us-west-2", DynamoDBEndpoint: "http://127.0.0.1:4567"}, c.Region)
+	}
+
+	_, err = dynamodb.NewClient(dynamodb.WithEndpoint("127.0.0.1:8000"))
+	assert.EqualError(t, err, "dynamodb: invalid endpoint '127.0.0.1:8000'")
+
+	assert.False(t, c.DisableCRC32Check)
+	t.Setenv("DYNAMODB_DISABLE_CRC32_CHECK", "true")
+	c, err = dynamodb.NewClient()
+	if assert.NoError(t, err) {
+		assert.True(t, c.DisableCRC32Check)
+	}
+	c, err = dynamodb.NewClient(dynamodb.WithDisableCRC32Check(false))
+	if assert.NoError(t, err) {
+		assert.False(t, c.DisableCRC32Check)
+	}
+
+	t.Setenv("DYNAMODB_DISABLE_CRC32_CHECK", "yes please")
+	_, err = dynamodb.NewClient()
+	assert.Error(t, err)
+}
+
+func TestNewClient_Options(t *testing.T) {
+	var header http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		w.Write([]byte(`{"TableNames":["TABLE"]}`))
+	}))
+	defer ts.Close()
+
+	var logged bool
+	hc := &http.Client{}
+	c, err := dynamodb.NewClient(
+		dynamodb.WithRegion("us-west-2"),
+		dynamodb.WithEndpoint(ts.URL),
+		dynamodb.WithCredentials(dynamodb.StaticCredentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET"}),
+		dynamodb.WithHTTPClient(hc),
+		dynamodb.WithRetryer(dynamodb.NoOpRetryer{}),
+		dynamodb.WithLogger(dynamodb.LoggerFunc(func(dynamodb.LogLevel, string) { logged = true }), dynamodb.LogInfo),
+		dynamodb.WithTimeout(time.Second),
+		dynamodb.WithConnectTimeout(time.Second),
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, dynamodb.NoOpRetryer{}, c.Retryer)
+	assert.Equal(t, time.Second, c.HTTPClient.Timeout)
+	assert.IsType(t, &http.Transport{}, c.HTTPClient.Transport)
+	assert.Nil(t, hc.Transport)
+
+	// the timeouts are kept regardless of the order of WithHTTPClient
+	c2, err := dynamodb.NewClient(
+		dynamodb.WithRegion("us-west-2"),
+		dynamodb.WithTimeout(time.Second),
+		dynamodb.WithConnectTimeout(time.Second),
+		dynamodb.WithHTTPClient(hc),
+		dynamodb.WithDisableCRC32Check(true),
+	)
+	if assert.NoError(t, err) {
+		assert.Equal(t, time.Second, c2.HTTPClient.Timeout)
+		assert.IsType(t, &http.Transport{}, c2.HTTPClient.Transport)
+		assert.True(t, c2.DisableCRC32Check)
+	}
+	assert.Nil(t, hc.Transport)
+	assert.Zero(t, hc.Timeout)
+
+	_, err = dynamodb.NewClient(dynamodb.WithRegion("us-west-2"), dynamodb.WithHTTPClient(nil))
+	assert.Equal(t, dynamodb.ErrHTTPClient, err)
+
+	c.DisableCRC32Check = true
+	ret, err := c.ListTables(nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"TABLE"}, ret.TableNames)
+	}
+	assert.Contains(t, header.Get("Authorization"), "Credential=AKID/")
+	assert.Contains(t, header.Get("Authorization"), "/us-west-2/dynamodb/aws4_request")
+	assert.True(t, logged)
+
+	_, err = dynamodb.NewClient(
+		dynamodb.WithRegion("us-east-1"),
+		dynamodb.WithHTTPClient(&http.Client{Transport: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+			return nil, errors.New("unused")
+		})}),
+		dynamodb.WithConnectTimeout(time.Second),
+	)
+	assert.Error(t, err)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
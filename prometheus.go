@@ -0,0 +1,183 @@
+package dynamodb
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultHistogramBuckets are upper bounds of the histogram buckets in seconds.
+var DefaultHistogramBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// PrometheusMetrics is Metrics which serves the metrics in the Prometheus text format.
+// The zero value is ready to use with DefaultHistogramBuckets.
+type PrometheusMetrics struct {
+	buckets []float64
+
+	mu         sync.Mutex
+	counters   map[string]map[string]float64
+	histograms map[string]map[string]*histogram
+}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewPrometheusMetrics returns PrometheusMetrics with buckets.
+// DefaultHistogramBuckets is used if no buckets are given.
+func NewPrometheusMetrics(buckets ...float64) *PrometheusMetrics {
+	if len(buckets) == 0 {
+		buckets = DefaultHistogramBuckets
+	}
+	b := append([]float64(nil), buckets...)
+	sort.Float64s(b)
+	return &PrometheusMetrics{
+		buckets:    b,
+		counters:   map[string]map[string]float64{},
+		histograms: map[string]map[string]*histogram{},
+	}
+}
+
+func (m *PrometheusMetrics) AddCounter(name string, labels Labels, delta float64) {
+	key := formatLabels(labels)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.counters == nil {
+		m.counters = map[string]map[string]float64{}
+	}
+	if m.counters[name] == nil {
+		m.counters[name] = map[string]float64{}
+	}
+	m.counters[name][key] += delta
+}
+
+func (m *PrometheusMetrics) ObserveHistogram(name string, labels Labels, value float64) {
+	key := formatLabels(labels)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.histograms == nil {
+		m.histograms = map[string]map[string]*histogram{}
+	}
+	if m.buckets == nil {
+		m.buckets = DefaultHistogramBuckets
+	}
+	if m.histograms[name] == nil {
+		m.histograms[name] = map[string]*histogram{}
+	}
+	h := m.histograms[name][key]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(m.buckets))}
+		m.histograms[name][key] = h
+	}
+	for i, b := range m.buckets {
+		if value <= b {
+			h.counts[i]++
+		}
+	}
+	h.sum += value
+	h.count++
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format.
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text format to w.
+func (m *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var sb strings.Builder
+	names := make([]string, 0, len(m.counters))
+	for name := range m.counters {
+		names = append(names, name)
+	}
+	for _, name := range sortStrings(names) {
+		fmt.Fprintf(&sb, "# TYPE %s counter\n", name)
+		series := m.counters[name]
+		keys := make([]string, 0, len(series))
+		for key := range series {
+			keys = append(keys, key)
+		}
+		for _, key := range sortStrings(keys) {
+			fmt.Fprintf(&sb, "%s%s %s\n", name, wrapLabels(key), formatFloat(series[key]))
+		}
+	}
+	names = names[:0]
+	for name := range m.histograms {
+		names = append(names, name)
+	}
+	for _, name := range sortStrings(names) {
+		fmt.Fprintf(&sb, "# TYPE %s histogram\n", name)
+		series := m.histograms[name]
+		keys := make([]string, 0, len(series))
+		for key := range series {
+			keys = append(keys, key)
+		}
+		for _, key := range sortStrings(keys) {
+			h := series[key]
+			for i, b := range m.buckets {
+				fmt.Fprintf(&sb, "%s_bucket%s %d\n", name, wrapLabels(joinLabels(key, `le="`+formatFloat(b)+`"`)), h.counts[i])
+			}
+			fmt.Fprintf(&sb, "%s_bucket%s %d\n", name, wrapLabels(joinLabels(key, `le="+Inf"`)), h.count)
+			fmt.Fprintf(&sb, "%s_sum%s %s\n", name, wrapLabels(key), formatFloat(h.sum))
+			fmt.Fprintf(&sb, "%s_count%s %d\n", name, wrapLabels(key), h.count)
+		}
+	}
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+func sortStrings(keys []string) []string {
+	sort.Strings(keys)
+	return keys
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatLabels formats labels as name="value" pairs sorted by name.
+func formatLabels(labels Labels) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, name+`="`+labelValueReplacer.Replace(labels[name])+`"`)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func joinLabels(key, label string) string {
+	if key == "" {
+		return label
+	}
+	return key + "," + label
+}
+
+func wrapLabels(key string) string {
+	if key == "" {
+		return ""
+	}
+	return "{" + key + "}"
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,239 @@
+package dynamodb
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Default values used by AdaptiveRateLimiter when its fields are zero.
+const (
+	DefaultMinRate              = 1.0
+	DefaultMaxRate              = 1000.0
+	DefaultRateDecreaseFactor   = 0.5
+	DefaultRateDecreaseInterval = time.Second
+)
+
+// RateLimiter paces requests per table on the client side.
+type RateLimiter interface {
+	// Wait blocks until a request to table is allowed or ctx is done.
+	Wait(ctx context.Context, table string) error
+
+	// Update reports the result of a request to table.
+	Update(table string, err error)
+}
+
+// AdaptiveRateLimiter is a token bucket per table whose rate is adjusted by AIMD.
+// The rate is multiplied by DecreaseFactor when a request is throttled,
+// and increased by IncreaseRate per second while requests succeed.
+// Every table starts at MaxRate with a burst of a second of requests,
+// so requests are limited to MaxRate until the table is throttled.
+//
+// Sharing a limiter between clients makes them back off together.
+type AdaptiveRateLimiter struct {
+	// MinRate and MaxRate bound the rate in requests per second.
+	// Non-positive values are replaced with DefaultMinRate and DefaultMaxRate,
+	// and MinRate is lowered to MaxRate if it is larger.
+	MinRate float64
+	MaxRate float64
+
+	// IncreaseRate is the requests per second added per second without throttling.
+	// It defaults to 5% of MaxRate.
+	IncreaseRate float64
+
+	// DecreaseFactor multiplies the rate on throttling.
+	DecreaseFactor float64
+
+	// DecreaseInterval is the minimum interval between decreases so that
+	// concurrent requests throttled at once decrease the rate only once.
+	DecreaseInterval time.Duration
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	rate         float64
+	tokens       float64
+	lastRefill   time.Time
+	lastIncrease time.Time
+	lastDecrease time.Time
+}
+
+// NewAdaptiveRateLimiter returns AdaptiveRateLimiter whose rate is between minRate and maxRate.
+// Invalid rates are replaced as MinRate and MaxRate describe.
+func NewAdaptiveRateLimiter(minRate, maxRate float64) *AdaptiveRateLimiter {
+	return &AdaptiveRateLimiter{
+		MinRate: minRate,
+		MaxRate: maxRate,
+	}
+}
+
+func (l *AdaptiveRateLimiter) Wait(ctx context.Context, table string) error {
+	l.mu.Lock()
+	b := l.bucket(table)
+	b.refill(time.Now())
+	b.tokens--
+	var d time.Duration
+	if b.tokens < 0 {
+		d = time.Duration(-b.tokens / math.Max(b.rate, l.minRate()) * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if d == 0 {
+		return nil
+	}
+	if err := sleep(ctx, d); err != nil {
+		// give the token back since the request is not sent
+		l.mu.Lock()
+		b.tokens++
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (l *AdaptiveRateLimiter) Update(table string, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.bucket(table)
+	now := time.Now()
+	b.refill(now)
+
+	switch {
+	case err == nil:
+		elapsed := now.Sub(b.lastIncrease).Seconds()
+		b.lastIncrease = now
+		b.rate = math.Min(l.maxRate(), b.rate+l.increaseRate()*elapsed)
+	case isThrottle(err):
+		b.lastIncrease = now
+		if now.Sub(b.lastDecrease) < l.decreaseInterval() {
+			return
+		}
+		b.lastDecrease = now
+		b.rate = math.Max(l.minRate(), b.rate*l.decreaseFactor())
+		if b.tokens > b.burst() {
+			b.tokens = b.burst()
+		}
+	}
+}
+
+// Rate returns the current rate of table in requests per second.
+func (l *AdaptiveRateLimiter) Rate(table string) float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.bucket(table).rate
+}
+
+func (l *AdaptiveRateLimiter) bucket(table string) *tokenBucket {
+	if l.buckets == nil {
+		l.buckets = map[string]*tokenBucket{}
+	}
+	b, ok := l.buckets[table]
+	if !ok {
+		now := time.Now()
+		b = &tokenBucket{
+			rate:         l.maxRate(),
+			lastRefill:   now,
+			lastIncrease: now,
+		}
+		b.tokens = b.burst()
+		l.buckets[table] = b
+	}
+	return b
+}
+
+func (l *AdaptiveRateLimiter) minRate() float64 {
+	if l.MinRate <= 0 {
+		return math.Min(DefaultMinRate, l.maxRate())
+	}
+	return math.Min(l.MinRate, l.maxRate())
+}
+
+func (l *AdaptiveRateLimiter) maxRate() float64 {
+	if l.MaxRate <= 0 {
+		return DefaultMaxRate
+	}
+	return l.MaxRate
+}
+
+func (l *AdaptiveRateLimiter) increaseRate() float64 {
+	if l.IncreaseRate == 0 {
+		return l.maxRate() * 0.05
+	}
+	return l.IncreaseRate
+}
+
+func (l *AdaptiveRateLimiter) decreaseFactor() float64 {
+	if l.DecreaseFactor == 0 {
+		return DefaultRateDecreaseFactor
+	}
+	return l.DecreaseFactor
+}
+
+func (l *AdaptiveRateLimiter) decreaseInterval() time.Duration {
+	if l.DecreaseInterval == 0 {
+		return DefaultRateDecreaseInterval
+	}
+	return l.DecreaseInterval
+}
+
+// burst allows requests for a second at most.
+func (b *tokenBucket) burst() float64 {
+	return math.Max(1, b.rate)
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.lastRefill).Seconds()
+	b.lastRefill = now
+	if elapsed > 0 {
+		b.tokens = math.Min(b.burst(), b.tokens+elapsed*b.rate)
+	}
+}
+
+// tableNames returns the names of tables which req operates on.
+// It looks for TableName and the keys of RequestItems in req.Param.
+func tableNames(req *RawRequest) []string {
+	names := map[string]struct{}{}
+	collectTableNames(reflect.ValueOf(req.Param), names)
+
+	ret := make([]string, 0, len(names))
+	for name := range names {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func collectTableNames(v reflect.Value, names map[string]struct{}) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f, fv := t.Field(i), v.Field(i)
+		switch {
+		case f.Anonymous:
+			collectTableNames(fv, names)
+		case f.Name == "TableName" && fv.Kind() == reflect.String:
+			if fv.String() != "" {
+				names[fv.String()] = struct{}{}
+			}
+		case f.Name == "RequestItems" && fv.Kind() == reflect.Map && fv.Type().Key().Kind() == reflect.String:
+			for _, k := range fv.MapKeys() {
+				names[k.String()] = struct{}{}
+			}
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package dynamodb_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nabeken/goamz-dynamodb"
+)
+
+var errThrottled = &dynamodb.Error{StatusCode: 400, Code: "ProvisionedThroughputExceededException"}
+
+func TestAdaptiveRateLimiter_AIMD(t *testing.T) {
+	l := dynamodb.NewAdaptiveRateLimiter(10, 100)
+	l.DecreaseInterval = time.Nanosecond
+	l.IncreaseRate = 1000
+
+	assert.Equal(t, 100.0, l.Rate("TABLE"))
+
+	l.Update("TABLE", errThrottled)
+	assert.Equal(t, 50.0, l.Rate("TABLE"))
+	time.Sleep(time.Millisecond)
+	l.Update("TABLE", errThrottled)
+	assert.Equal(t, 25.0, l.Rate("TABLE"))
+	for i := 0; i < 5; i++ {
+		time.Sleep(time.Millisecond)
+		l.Update("TABLE", errThrottled)
+	}
+	assert.Equal(t, 10.0, l.Rate("TABLE"), "rate must not go below MinRate")
+
+	// other errors don't change the rate
+	l.Update("TABLE", &dynamodb.Error{StatusCode: 400, Code: "ValidationException"})
+	assert.Equal(t, 10.0, l.Rate("TABLE"))
+
+	// tables are independent
+	assert.Equal(t, 100.0, l.Rate("OTHER_TABLE"))
+
+	time.Sleep(20 * time.Millisecond)
+	l.Update("TABLE", nil)
+	assert.True(t, l.Rate("TABLE") >= 30, "rate must increase while requests succeed: %f", l.Rate("TABLE"))
+
+	time.Sleep(100 * time.Millisecond)
+	l.Update("TABLE", nil)
+	assert.Equal(t, 100.0, l.Rate("TABLE"), "rate must not go above MaxRate")
+}
+
+func TestAdaptiveRateLimiter_DecreaseInterval(t *testing.T) {
+	l := dynamodb.NewAdaptiveRateLimiter(1, 100)
+
+	// concurrent throttled requests decrease the rate only once
+	for i := 0; i < 10; i++ {
+		l.Update("TABLE", errThrottled)
+	}
+	assert.Equal(t, 50.0, l.Rate("TABLE"))
+}
+
+func TestAdaptiveRateLimiter_Wait(t *testing.T) {
+	l := dynamodb.NewAdaptiveRateLimiter(10, 10)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, l.Wait(ctx, "TABLE"))
+	}
+	assert.True(t, time.Since(start) < 50*time.Millisecond, "burst must not be limited")
+
+	start = time.Now()
+	assert.NoError(t, l.Wait(ctx, "TABLE"))
+	assert.True(t, time.Since(start) >= 80*time.Millisecond, "request must wait for a token")
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, l.Wait(ctx, "TABLE"))
+}
+
+func TestAdaptiveRateLimiter_InvalidRate(t *testing.T) {
+	for _, l := range []*dynamodb.AdaptiveRateLimiter{
+		dynamodb.NewAdaptiveRateLimiter(0, 10),
+		dynamodb.NewAdaptiveRateLimiter(-1, 10),
+		dynamodb.NewAdaptiveRateLimiter(20, 10),
+		{MaxRate: 10},
+	} {
+		l.DecreaseInterval = time.Nanosecond
+		for i := 0; i < 100; i++ {
+			time.Sleep(time.Microsecond)
+			l.Update("TABLE", errThrottled)
+		}
+		assert.True(t, l.Rate("TABLE") > 0, "rate must stay positive: %+v", l)
+		assert.True(t, l.Rate("TABLE") <= 10, "rate must not go above MaxRate: %+v", l)
+	}
+
+	// the zero value limits requests
+	l := &dynamodb.AdaptiveRateLimiter{DecreaseInterval: time.Nanosecond}
+	assert.Equal(t, dynamodb.DefaultMaxRate, l.Rate("TABLE"))
+	for i := 0; i < 100; i++ {
+		time.Sleep(time.Microsecond)
+		l.Update("TABLE", errThrottled)
+	}
+	assert.Equal(t, dynamodb.DefaultMinRate, l.Rate("TABLE"))
+
+	ctx := context.Background()
+	assert.NoError(t, l.Wait(ctx, "TABLE"))
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, l.Wait(ctx, "TABLE"), "request must wait for a token")
+}
+
+func TestClient_RateLimiter(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#ProvisionedThroughputExceededException","message":"throttled"}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	l := dynamodb.NewAdaptiveRateLimiter(1, 100)
+	c := &dynamodb.Client{
+		Auth:              dummyAuth,
+		Region:            dynamodb.Region{DynamoDBEndpoint: ts.URL},
+		DisableCRC32Check: true,
+		Retryer:           dynamodb.DefaultRetryer{MinThrottleDelay: time.Millisecond},
+		RateLimiter:       l,
+	}
+
+	_, err := c.BatchGetItem(map[string]dynamodb.KeysAndAttributes{
+		"TABLE1": dynamodb.KeysAndAttributes{
+			Keys: []map[string]dynamodb.AttributeValue{{"HashKey": dynamodb.NewString("HASH")}},
+		},
+		"TABLE2": dynamodb.KeysAndAttributes{
+			Keys: []map[string]dynamodb.AttributeValue{{"HashKey": dynamodb.NewString("HASH")}},
+		},
+	}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, requests)
+	assert.True(t, l.Rate("TABLE1") < 100)
+	assert.True(t, l.Rate("TABLE2") < 100)
+	assert.Equal(t, 100.0, l.Rate("TABLE3"))
+
+	_, err = c.GetItem("TABLE3", map[string]dynamodb.AttributeValue{"HashKey": dynamodb.NewString("HASH")}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 100.0, l.Rate("TABLE3"))
+}
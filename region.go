@@ -0,0 +1,75 @@
+package dynamodb
+
+import "strings"
+
+// DefaultSigningRegion is the region to sign requests for a custom endpoint without Region.Name.
+const DefaultSigningRegion = "us-east-1"
+
+// Region is a region of DynamoDB.
+type Region struct {
+	Name             string
+	DynamoDBEndpoint string
+}
+
+// NewRegion returns Region with the endpoint of the partition which name belongs to.
+func NewRegion(name string) Region {
+	suffix := "amazonaws.com"
+	if strings.HasPrefix(name, "cn-") {
+		suffix = "amazonaws.com.cn"
+	}
+	return Region{
+		Name:             name,
+		DynamoDBEndpoint: "https://dynamodb." + name + "." + suffix,
+	}
+}
+
+func (r Region) signingName() string {
+	if r.Name == "" {
+		return DefaultSigningRegion
+	}
+	return r.Name
+}
+
+var (
+	USEast       = NewRegion("us-east-1")
+	USEast2      = NewRegion("us-east-2")
+	USWest       = NewRegion("us-west-1")
+	USWest2      = NewRegion("us-west-2")
+	USGovWest    = NewRegion("us-gov-west-1")
+	USGovEast    = NewRegion("us-gov-east-1")
+	CACentral    = NewRegion("ca-central-1")
+	SAEast       = NewRegion("sa-east-1")
+	EUWest       = NewRegion("eu-west-1")
+	EUWest2      = NewRegion("eu-west-2")
+	EUWest3      = NewRegion("eu-west-3")
+	EUCentral    = NewRegion("eu-central-1")
+	EUNorth      = NewRegion("eu-north-1")
+	EUSouth      = NewRegion("eu-south-1")
+	APNortheast  = NewRegion("ap-northeast-1")
+	APNortheast2 = NewRegion("ap-northeast-2")
+	APNortheast3 = NewRegion("ap-northeast-3")
+	APSoutheast  = NewRegion("ap-southeast-1")
+	APSoutheast2 = NewRegion("ap-southeast-2")
+	APSouth      = NewRegion("ap-south-1")
+	APEast       = NewRegion("ap-east-1")
+	MESouth      = NewRegion("me-south-1")
+	AFSouth      = NewRegion("af-south-1")
+	CNNorth      = NewRegion("cn-north-1")
+	CNNorthwest  = NewRegion("cn-northwest-1")
+)
+
+// Regions is the known regions by name.
+var Regions = map[string]Region{}
+
+func init() {
+	for _, r := range []Region{
+		USEast, USEast2, USWest, USWest2, USGovWest, USGovEast,
+		CACentral, SAEast,
+		EUWest, EUWest2, EUWest3, EUCentral, EUNorth, EUSouth,
+		APNortheast, APNortheast2, APNortheast3, APSoutheast, APSoutheast2, APSouth, APEast,
+		MESouth, AFSouth,
+		CNNorth, CNNorthwest,
+	} {
+		Regions[r.Name] = r
+	}
+}
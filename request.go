@@ -18,11 +18,25 @@ type ListTablesOption struct {
 	Limit                   uint   `json:",omitempty"`
 }
 
+type ListTagsOfResourceOption struct {
+	NextToken string `json:",omitempty"`
+}
+
 type UpdateTableOption struct {
 	GlobalSecondaryIndexUpdates []GlobalSecondaryIndexUpdate `json:",omitempty"`
 	ProvisionedThroughput       ProvisionedThroughput        `json:",omitempty"`
 }
 
+type BatchExecuteStatementOption struct {
+	ReturnConsumedCapacity ReturnConsumedCapacity `json:",omitempty"`
+}
+
+type BatchStatementRequest struct {
+	ConsistentRead bool             `json:",omitempty"`
+	Parameters     []AttributeValue `json:",omitempty"`
+	Statement      string
+}
+
 type BatchGetItemOption struct {
 	ReturnConsumedCapacity ReturnConsumedCapacity `json:",omitempty"`
 }
@@ -48,12 +62,29 @@ func (r DeleteRequest) IsEmpty() bool {
 	return len(r.Key) == 0
 }
 
+type ExecuteStatementOption struct {
+	ConsistentRead         bool                   `json:",omitempty"`
+	Limit                  uint                   `json:",omitempty"`
+	NextToken              string                 `json:",omitempty"`
+	ReturnConsumedCapacity ReturnConsumedCapacity `json:",omitempty"`
+}
+
+type ExecuteTransactionOption struct {
+	ClientRequestToken     string                 `json:",omitempty"`
+	ReturnConsumedCapacity ReturnConsumedCapacity `json:",omitempty"`
+}
+
 type GetItemOption struct {
 	AttributesToGet        []string               `json:",omitempty"`
 	ConsistentRead         bool                   `json:",omitempty"`
 	ReturnConsumedCapacity ReturnConsumedCapacity `json:",omitempty"`
 }
 
+type ParameterizedStatement struct {
+	Parameters []AttributeValue `json:",omitempty"`
+	Statement  string
+}
+
 type PutItemOption struct {
 	ConditionalOperator         ConditionalOperator         `json:",omitempty"`
 	Expected                    ExpectedAttributeValue      `json:",omitempty"`
@@ -150,6 +150,16 @@ func TestCreateTable_Full(t *testing.T) {
 				]
 			}
 		}
+	],
+	"Tags": [
+		{
+			"Key": "Owner",
+			"Value": "OWNER"
+		},
+		{
+			"Key": "CostCenter",
+			"Value": "COST_CENTER"
+		}
 	]
 }
 `)
@@ -234,6 +244,10 @@ func TestCreateTable_Full(t *testing.T) {
 				},
 			},
 		},
+		Tags: []dynamodb.Tag{
+			dynamodb.Tag{"Owner", "OWNER"},
+			dynamodb.Tag{"CostCenter", "COST_CENTER"},
+		},
 	}
 	expectedRequest := dynamodb.TableOption{}
 	if !assert.NoError(t, json.Unmarshal(expectedJSON, &expectedRequest)) {
@@ -286,6 +300,52 @@ func TestDeleteItemOption(t *testing.T) {
 	assert.Equal(t, expectedRequest, q)
 }
 
+func TestExecuteStatementOption(t *testing.T) {
+	expectedJSON := []byte(`
+{
+	"ConsistentRead": true,
+	"Limit": 10,
+	"NextToken": "EXECUTE_STATEMENT_NEXT_TOKEN",
+	"ReturnConsumedCapacity": "TOTAL"
+}
+`)
+	q := dynamodb.ExecuteStatementOption{
+		ConsistentRead:         true,
+		Limit:                  10,
+		NextToken:              "EXECUTE_STATEMENT_NEXT_TOKEN",
+		ReturnConsumedCapacity: dynamodb.ConsumedCapTotal,
+	}
+	expectedRequest := dynamodb.ExecuteStatementOption{}
+	if !assert.NoError(t, json.Unmarshal(expectedJSON, &expectedRequest)) {
+		t.Fail()
+	}
+	assert.Equal(t, expectedRequest, q)
+}
+
+func TestParameterizedStatement(t *testing.T) {
+	expectedJSON := []byte(`
+{
+	"Parameters": [
+		{"S": "STRING"},
+		{"N": "1"}
+	],
+	"Statement": "UPDATE \"TABLE\" SET ATTR=? WHERE HASHKEY=?"
+}
+`)
+	q := dynamodb.ParameterizedStatement{
+		Parameters: []dynamodb.AttributeValue{
+			dynamodb.NewString("STRING"),
+			dynamodb.NewNumber(1),
+		},
+		Statement: `UPDATE "TABLE" SET ATTR=? WHERE HASHKEY=?`,
+	}
+	expectedRequest := dynamodb.ParameterizedStatement{}
+	if !assert.NoError(t, json.Unmarshal(expectedJSON, &expectedRequest)) {
+		t.Fail()
+	}
+	assert.Equal(t, expectedRequest, q)
+}
+
 func TestGetItemOption(t *testing.T) {
 	expectedJSON := []byte(`
 {
@@ -327,6 +387,22 @@ func TestListTableOption(t *testing.T) {
 	assert.Equal(t, expectedRequest, q)
 }
 
+func TestListTagsOfResourceOption(t *testing.T) {
+	expectedJSON := []byte(`
+{
+	"NextToken": "LIST_TAGS_OF_RESOURCE_NEXT_TOKEN"
+}
+`)
+	q := dynamodb.ListTagsOfResourceOption{
+		NextToken: "LIST_TAGS_OF_RESOURCE_NEXT_TOKEN",
+	}
+	expectedRequest := dynamodb.ListTagsOfResourceOption{}
+	if !assert.NoError(t, json.Unmarshal(expectedJSON, &expectedRequest)) {
+		t.Fail()
+	}
+	assert.Equal(t, expectedRequest, q)
+}
+
 func TestPutItemOption(t *testing.T) {
 	expectedJSON := []byte(`
 {
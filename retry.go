@@ -0,0 +1,158 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/url"
+	"time"
+)
+
+// Default values used by DefaultRetryer when its fields are zero.
+const (
+	DefaultMaxRetries       = 10
+	DefaultMinRetryDelay    = 50 * time.Millisecond
+	DefaultMaxRetryDelay    = 5 * time.Second
+	DefaultMinThrottleDelay = 500 * time.Millisecond
+	DefaultMaxThrottleDelay = 20 * time.Second
+)
+
+// Retryer decides whether and when a failed request is retried.
+type Retryer interface {
+	// MaxRetries returns the maximum number of retries after the first attempt.
+	MaxRetries() int
+
+	// ShouldRetry reports whether a request failed with err should be retried.
+	ShouldRetry(err error) bool
+
+	// RetryDelay returns the delay before the retry-th retry. retry starts at 1.
+	RetryDelay(retry int, err error) time.Duration
+}
+
+// DefaultRetryer retries throttling and transient errors with exponential backoff and jitter.
+// Throttling errors back off from a longer delay than transient errors
+// so that a throttled table has time to recover.
+// Zero fields are replaced with the Default* constants.
+type DefaultRetryer struct {
+	NumMaxRetries    int
+	MinRetryDelay    time.Duration
+	MaxRetryDelay    time.Duration
+	MinThrottleDelay time.Duration
+	MaxThrottleDelay time.Duration
+}
+
+func (r DefaultRetryer) MaxRetries() int {
+	if r.NumMaxRetries == 0 {
+		return DefaultMaxRetries
+	}
+	return r.NumMaxRetries
+}
+
+func (r DefaultRetryer) ShouldRetry(err error) bool {
+	return shouldRetry(err)
+}
+
+func (r DefaultRetryer) RetryDelay(retry int, err error) time.Duration {
+	min, max := r.MinRetryDelay, r.MaxRetryDelay
+	if min == 0 {
+		min = DefaultMinRetryDelay
+	}
+	if max == 0 {
+		max = DefaultMaxRetryDelay
+	}
+	if isThrottle(err) {
+		min, max = r.MinThrottleDelay, r.MaxThrottleDelay
+		if min == 0 {
+			min = DefaultMinThrottleDelay
+		}
+		if max == 0 {
+			max = DefaultMaxThrottleDelay
+		}
+	}
+	return backoff(retry, min, max)
+}
+
+// NoOpRetryer never retries.
+type NoOpRetryer struct{}
+
+func (r NoOpRetryer) MaxRetries() int                               { return 0 }
+func (r NoOpRetryer) ShouldRetry(err error) bool                    { return false }
+func (r NoOpRetryer) RetryDelay(retry int, err error) time.Duration { return 0 }
+
+// backoff returns min * 2^(retry-1) capped by max with equal jitter,
+// that is, a random duration between a half of the delay and the delay.
+func backoff(retry int, min, max time.Duration) time.Duration {
+	d := min
+	for i := 1; i < retry && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
+
+// Based on github.com/crowdmob/goamz/s3
+func shouldRetry(err error) bool {
+	return isThrottle(err) || isTransient(err)
+}
+
+func isThrottle(err error) bool {
+	switch ErrorCode(err) {
+	case CodeThrottling, CodeProvisionedThroughputExceeded, CodeRequestLimitExceeded:
+		return true
+	}
+	return false
+}
+
+func isTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	// errors from http.Client are wrapped in *url.Error
+	if ue, ok := err.(*url.Error); ok {
+		err = ue.Err
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) || errors.Is(err, ErrFailedtoReadResponse) || errors.Is(err, ErrCRC32Mismatch) {
+		return true
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		switch opErr.Op {
+		case "read", "write":
+			return true
+		}
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		switch e.Code {
+		case "InternalError", "InternalFailure", CodeInternalServerError, "ServiceUnavailable":
+			return true
+		}
+		switch e.StatusCode {
+		case 500, 502, 503, 504:
+			return true
+		}
+	}
+	return false
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,70 @@
+package dynamodb_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nabeken/goamz-dynamodb"
+)
+
+func TestDefaultRetryer_ShouldRetry(t *testing.T) {
+	r := dynamodb.DefaultRetryer{}
+	for _, err := range []error{
+		io.ErrUnexpectedEOF,
+		&dynamodb.Error{StatusCode: 400, Code: "ThrottlingException"},
+		&dynamodb.Error{StatusCode: 400, Code: "ProvisionedThroughputExceededException"},
+		&dynamodb.Error{StatusCode: 400, Code: "RequestLimitExceeded"},
+		&dynamodb.Error{StatusCode: 500, Code: "InternalServerError"},
+		&dynamodb.Error{StatusCode: 503, Code: "ServiceUnavailable"},
+	} {
+		assert.True(t, r.ShouldRetry(err), "%v should be retried", err)
+	}
+	for _, err := range []error{
+		nil,
+		errors.New("dynamodb: error"),
+		&dynamodb.Error{StatusCode: 400, Code: "ConditionalCheckFailedException"},
+		&dynamodb.Error{StatusCode: 400, Code: "ValidationException"},
+	} {
+		assert.False(t, r.ShouldRetry(err), "%v should not be retried", err)
+	}
+}
+
+func TestDefaultRetryer_RetryDelay(t *testing.T) {
+	r := dynamodb.DefaultRetryer{
+		MinRetryDelay:    10 * time.Millisecond,
+		MaxRetryDelay:    100 * time.Millisecond,
+		MinThrottleDelay: 100 * time.Millisecond,
+		MaxThrottleDelay: time.Second,
+	}
+	transient := &dynamodb.Error{StatusCode: 500, Code: "InternalServerError"}
+	throttle := &dynamodb.Error{StatusCode: 400, Code: "ProvisionedThroughputExceededException"}
+
+	for _, tc := range []struct {
+		retry    int
+		err      error
+		min, max time.Duration
+	}{
+		{1, transient, 5 * time.Millisecond, 10 * time.Millisecond},
+		{2, transient, 10 * time.Millisecond, 20 * time.Millisecond},
+		{3, transient, 20 * time.Millisecond, 40 * time.Millisecond},
+		{10, transient, 50 * time.Millisecond, 100 * time.Millisecond},
+		{1, throttle, 50 * time.Millisecond, 100 * time.Millisecond},
+		{3, throttle, 200 * time.Millisecond, 400 * time.Millisecond},
+		{10, throttle, 500 * time.Millisecond, time.Second},
+	} {
+		for i := 0; i < 100; i++ {
+			d := r.RetryDelay(tc.retry, tc.err)
+			assert.True(t, d >= tc.min && d <= tc.max, "retry %d: %s is not in [%s, %s]", tc.retry, d, tc.min, tc.max)
+		}
+	}
+}
+
+func TestDefaultRetryer_MaxRetries(t *testing.T) {
+	assert.Equal(t, dynamodb.DefaultMaxRetries, dynamodb.DefaultRetryer{}.MaxRetries())
+	assert.Equal(t, 3, dynamodb.DefaultRetryer{NumMaxRetries: 3}.MaxRetries())
+	assert.Equal(t, 0, dynamodb.NoOpRetryer{}.MaxRetries())
+}
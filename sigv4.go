@@ -0,0 +1,159 @@
+package dynamodb
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// ISO8601BasicFormat is the format of X-Amz-Date.
+	ISO8601BasicFormat = "20060102T150405Z"
+	// ISO8601BasicFormatShort is the format of the date in the credential scope.
+	ISO8601BasicFormatShort = "20060102"
+
+	sigV4Algorithm = "AWS4-HMAC-SHA256"
+)
+
+// Signer signs HTTP requests with AWS Signature Version 4.
+type Signer struct {
+	Credentials Credentials
+	Region      string
+	Service     string
+}
+
+// Sign sets X-Amz-Date, X-Amz-Security-Token if any and Authorization of req
+// as it is signed at t. body must be the payload of req.
+func (s *Signer) Sign(req *http.Request, body []byte, t time.Time) {
+	t = t.UTC()
+	date := t.Format(ISO8601BasicFormat)
+	req.Header.Set("X-Amz-Date", date)
+	if s.Credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.Credentials.SessionToken)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers, signedHeaders := canonicalHeaders(req.Header, host)
+	creq := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL),
+		canonicalQuery(req.URL),
+		headers,
+		signedHeaders,
+		hexSHA256(body),
+	}, "\n")
+
+	scope := strings.Join([]string{t.Format(ISO8601BasicFormatShort), s.Region, s.Service, "aws4_request"}, "/")
+	sts := strings.Join([]string{sigV4Algorithm, date, scope, hexSHA256([]byte(creq))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.Credentials.SecretAccessKey), t.Format(ISO8601BasicFormatShort))
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, s.Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, sts))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, s.Credentials.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalHeaders returns the canonical headers terminated by a newline and the signed headers.
+// All the headers except Authorization are signed.
+func canonicalHeaders(h http.Header, host string) (string, string) {
+	values := map[string][]string{"host": {host}}
+	for k, vs := range h {
+		name := strings.ToLower(k)
+		if name == "authorization" {
+			continue
+		}
+		values[name] = append(values[name], vs...)
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		vs := make([]string, len(values[name]))
+		for i, v := range values[name] {
+			vs[i] = strings.Join(strings.Fields(v), " ")
+		}
+		b.WriteString(name + ":" + strings.Join(vs, ",") + "\n")
+	}
+	return b.String(), strings.Join(names, ";")
+}
+
+// canonicalURI returns the normalized and URI-encoded path.
+func canonicalURI(u *url.URL) string {
+	p := u.Path
+	if p == "" {
+		return "/"
+	}
+	cleaned := path.Clean(p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	segments := strings.Split(cleaned, "/")
+	for i, s := range segments {
+		segments[i] = uriEncode(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery returns the query sorted by the encoded keys and values.
+func canonicalQuery(u *url.URL) string {
+	var pairs []string
+	for _, kv := range strings.Split(u.RawQuery, "&") {
+		if kv == "" {
+			continue
+		}
+		var k, v string
+		if i := strings.Index(kv, "="); i >= 0 {
+			k, v = kv[:i], kv[i+1:]
+		} else {
+			k = kv
+		}
+		k, _ = url.QueryUnescape(k)
+		v, _ = url.QueryUnescape(v)
+		pairs = append(pairs, uriEncode(k)+"="+uriEncode(v))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode encodes s except the unreserved characters in RFC 3986.
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func hexSHA256(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
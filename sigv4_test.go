@@ -0,0 +1,91 @@
+package dynamodb_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nabeken/goamz-dynamodb"
+)
+
+// The vectors are from the official AWS Signature Version 4 test suite.
+func TestSigner_TestSuite(t *testing.T) {
+	signer := &dynamodb.Signer{
+		Credentials: dynamodb.Credentials{
+			AccessKeyID:     "AKIDEXAMPLE",
+			SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		},
+		Region:  "us-east-1",
+		Service: "service",
+	}
+	date := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name          string
+		method        string
+		path          string
+		query         string
+		header        map[string]string
+		body          string
+		signedHeaders string
+		signature     string
+	}{
+		{"get-vanilla", "GET", "/", "", nil, "", "host;x-amz-date", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{"get-vanilla-query", "GET", "/", "", nil, "", "host;x-amz-date", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{"get-vanilla-empty-query-key", "GET", "/", "Param1=value1", nil, "", "host;x-amz-date", "a67d582fa61cc504c4bae71f336f98b97f1ea3c7a6bfe1b6e45aec72011b9aeb"},
+		{"get-vanilla-query-order-key-case", "GET", "/", "Param2=value2&Param1=value1", nil, "", "host;x-amz-date", "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"},
+		{"get-vanilla-query-unreserved", "GET", "/", "-._~0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz=-._~0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz", nil, "", "host;x-amz-date", "9c3e54bfcdf0b19771a7f523ee5669cdf59bc7cc0884027167c21bb143a40197"},
+		{"get-vanilla-utf8-query", "GET", "/", "ሴ=bar", nil, "", "host;x-amz-date", "2cdec8eed098649ff3a119c94853b13c643bcf08f8b0a1d91e12c9027818dd04"},
+		{"get-header-value-trim", "GET", "/", "", map[string]string{"My-Header1": " value1", "My-Header2": ` "a   b   c"`}, "", "host;my-header1;my-header2;x-amz-date", "acc3ed3afb60bb290fc8d2dd0098b9911fcaa05412b367055dee359757a9c736"},
+		{"get-space", "GET", "/example space/", "", nil, "", "host;x-amz-date", "652487583200325589f1fba4c7e578f72c47cb61beeca81406b39ddec1366741"},
+		{"get-slash", "GET", "//", "", nil, "", "host;x-amz-date", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{"get-slash-dot-slash", "GET", "/./", "", nil, "", "host;x-amz-date", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{"get-relative-relative", "GET", "/example1/example2/../..", "", nil, "", "host;x-amz-date", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{"get-unreserved", "GET", "/-._~0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz", "", nil, "", "host;x-amz-date", "07ef7494c76fa4850883e2b006601f940f8a34d404d0cfa977f52a65bbf5f24f"},
+		{"get-utf8", "GET", "/ሴ", "", nil, "", "host;x-amz-date", "8318018e0b0f223aa2bbf98705b62bb787dc9c0e678f255a891fd03141be5d85"},
+		{"post-vanilla", "POST", "/", "", nil, "", "host;x-amz-date", "5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b"},
+		{"post-vanilla-query", "POST", "/", "Param1=value1", nil, "", "host;x-amz-date", "28038455d6de14eafc1f9222cf5aa6f1a96197d7deb8263271d420d138af7f11"},
+		{"post-header-key-sort", "POST", "/", "", map[string]string{"My-Header1": "value1"}, "", "host;my-header1;x-amz-date", "c5410059b04c1ee005303aed430f6e6645f61f4dc9e1461ec8f8916fdf18852c"},
+		{"post-header-value-case", "POST", "/", "", map[string]string{"My-Header1": "VALUE1"}, "", "host;my-header1;x-amz-date", "cdbc9802e29d2942e5e10b5bccfdd67c5f22c7c4e8ae67b53629efa58b974b7d"},
+		{"post-x-www-form-urlencoded", "POST", "/", "", map[string]string{"Content-Type": "application/x-www-form-urlencoded"}, "Param1=value1", "content-type;host;x-amz-date", "ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a"},
+		{"post-x-www-form-urlencoded-parameters", "POST", "/", "", map[string]string{"Content-Type": "application/x-www-form-urlencoded; charset=utf8"}, "Param1=value1", "content-type;host;x-amz-date", "1a72ec8f64bd914b0e42e42607c7fbce7fb2c7465f63e3092b3b0d39fa77a6fe"},
+	} {
+		req, err := http.NewRequest(tc.method, "http://example.amazonaws.com/", strings.NewReader(tc.body))
+		if !assert.NoError(t, err) {
+			continue
+		}
+		req.URL.Path = tc.path
+		req.URL.RawQuery = tc.query
+		for k, v := range tc.header {
+			req.Header.Set(k, v)
+		}
+		signer.Sign(req, []byte(tc.body), date)
+
+		assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"), tc.name)
+		assert.Equal(t,
+			"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders="+tc.signedHeaders+", Signature="+tc.signature,
+			req.Header.Get("Authorization"), tc.name)
+	}
+}
+
+func TestSigner_SessionToken(t *testing.T) {
+	req, _ := http.NewRequest("POST", "https://dynamodb.us-east-1.amazonaws.com/", nil)
+	signer := &dynamodb.Signer{
+		Credentials: dynamodb.Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET", SessionToken: "TOKEN"},
+		Region:      "us-east-1",
+		Service:     "dynamodb",
+	}
+	signer.Sign(req, nil, time.Now())
+	assert.Equal(t, "TOKEN", req.Header.Get("X-Amz-Security-Token"))
+	assert.Contains(t, req.Header.Get("Authorization"), "SignedHeaders=host;x-amz-date;x-amz-security-token,")
+}
+
+func TestRegions(t *testing.T) {
+	assert.Equal(t, "https://dynamodb.us-east-1.amazonaws.com", dynamodb.USEast.DynamoDBEndpoint)
+	assert.Equal(t, "https://dynamodb.cn-north-1.amazonaws.com.cn", dynamodb.Regions["cn-north-1"].DynamoDBEndpoint)
+	assert.Equal(t, dynamodb.APNortheast, dynamodb.Regions["ap-northeast-1"])
+	assert.Equal(t, dynamodb.Region{Name: "xx-test-1", DynamoDBEndpoint: "https://dynamodb.xx-test-1.amazonaws.com"}, dynamodb.NewRegion("xx-test-1"))
+}
@@ -0,0 +1,161 @@
+package sqldriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"time"
+
+	"github.com/nabeken/goamz-dynamodb"
+)
+
+type conn struct {
+	c  *dynamodb.Client
+	tx *tx
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{c, query}, nil
+}
+
+func (c *conn) Close() error {
+	c.tx = nil
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, _ driver.TxOptions) (driver.Tx, error) {
+	if c.tx != nil {
+		return nil, ErrTxInProgress
+	}
+	c.tx = &tx{ctx: ctx, conn: c}
+	return c.tx, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	params, err := bindParameters(args)
+	if err != nil {
+		return nil, err
+	}
+	if c.tx != nil {
+		c.tx.statements = append(c.tx.statements, dynamodb.ParameterizedStatement{
+			Parameters: params,
+			Statement:  query,
+		})
+		return driver.ResultNoRows, nil
+	}
+	if _, err := c.c.ExecuteStatementWithContext(ctx, query, params, nil); err != nil {
+		return nil, err
+	}
+	return driver.ResultNoRows, nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if c.tx != nil {
+		return nil, ErrQueryInTx
+	}
+	params, err := bindParameters(args)
+	if err != nil {
+		return nil, err
+	}
+	return newRows(ctx, c.c, query, params)
+}
+
+// CheckNamedValue implements driver.NamedValueChecker to pass
+// dynamodb.AttributeValue, sets, maps and lists through to the statement as is.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	switch nv.Value.(type) {
+	case dynamodb.AttributeValue, []string, []int, map[string]dynamodb.AttributeValue, []dynamodb.AttributeValue:
+		return nil
+	}
+	v, err := driver.DefaultParameterConverter.ConvertValue(nv.Value)
+	if err != nil {
+		return err
+	}
+	nv.Value = v
+	return nil
+}
+
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+// NumInput returns -1 since placeholders are counted by DynamoDB.
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.conn.ExecContext(context.Background(), s.query, namedValues(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.conn.QueryContext(context.Background(), s.query, namedValues(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+// tx buffers statements and executes them in ExecuteTransaction on Commit.
+type tx struct {
+	ctx        context.Context
+	conn       *conn
+	statements []dynamodb.ParameterizedStatement
+}
+
+func (t *tx) Commit() error {
+	if t.conn.tx != t {
+		return ErrTxDone
+	}
+	t.conn.tx = nil
+	if len(t.statements) == 0 {
+		return nil
+	}
+	_, err := t.conn.c.ExecuteTransactionWithContext(t.ctx, t.statements, nil)
+	return err
+}
+
+func (t *tx) Rollback() error {
+	if t.conn.tx != t {
+		return ErrTxDone
+	}
+	t.conn.tx = nil
+	return nil
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	nvs := make([]driver.NamedValue, len(args))
+	for i := range args {
+		nvs[i] = driver.NamedValue{Ordinal: i + 1, Value: args[i]}
+	}
+	return nvs
+}
+
+// bindParameters binds args to '?' placeholders in order. Named arguments are not supported.
+func bindParameters(args []driver.NamedValue) ([]dynamodb.AttributeValue, error) {
+	vals := make([]interface{}, len(args))
+	for i := range args {
+		if args[i].Name != "" {
+			return nil, ErrNamedArgument
+		}
+		switch v := args[i].Value.(type) {
+		case time.Time:
+			vals[i] = v.UTC().Format(time.RFC3339Nano)
+		default:
+			vals[i] = v
+		}
+	}
+	return dynamodb.NewParameters(vals...)
+}
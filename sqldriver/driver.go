@@ -0,0 +1,125 @@
+// Package sqldriver provides a database/sql driver for DynamoDB backed by PartiQL.
+//
+// The driver is registered as "dynamodb":
+//
+//	db, err := sql.Open("dynamodb", "region=us-east-1")
+//
+// The DSN is a URL-encoded query string with the following keys:
+//
+//	region               region name such as "us-east-1". Regions unknown to dynamodb.Regions are
+//	                     resolved with dynamodb.NewRegion.
+//	endpoint             DynamoDB endpoint which overrides the endpoint of region
+//	access_key           AWS access key. If omitted, credentials are read from the environment variables,
+//	                     the shared files, the container endpoint or the instance metadata, and refreshed.
+//	secret_key           AWS secret key
+//	disable_crc32_check  "true" to disable the CRC32 check
+//
+// An existing *dynamodb.Client can be used with NewConnector and sql.OpenDB.
+//
+// Query and Exec are mapped to ExecuteStatement and Tx is mapped to ExecuteTransaction.
+// Placeholders are written as '?' and bound to AttributeValue parameters in order.
+// map[string]dynamodb.AttributeValue and []dynamodb.AttributeValue are bound as maps and lists.
+//
+// Items have no fixed schema, so the columns of Rows are the attribute names in the first
+// non-empty page of the result. Since database/sql cannot add columns while reading, Next
+// fails with ErrUnknownColumn if an item in a later page has another attribute.
+package sqldriver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net/url"
+
+	"github.com/nabeken/goamz-dynamodb"
+)
+
+// DriverName is the name of the driver registered to database/sql.
+const DriverName = "dynamodb"
+
+var (
+	ErrQueryInTx     = errors.New("sqldriver: query is not supported in a transaction")
+	ErrTxInProgress  = errors.New("sqldriver: transaction is already in progress")
+	ErrTxDone        = errors.New("sqldriver: transaction has already been committed or rolled back")
+	ErrInvalidRegion = errors.New("sqldriver: region or endpoint is required")
+	ErrNamedArgument = errors.New("sqldriver: named arguments are not supported")
+	ErrUnknownColumn = errors.New("sqldriver: attribute is not in the columns of the first page")
+)
+
+func init() {
+	sql.Register(DriverName, &Driver{})
+}
+
+// Driver implements driver.Driver and driver.DriverContext.
+type Driver struct{}
+
+func (d *Driver) Open(dsn string) (driver.Conn, error) {
+	c, err := d.OpenConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return c.(*connector).open(), nil
+}
+
+func (d *Driver) OpenConnector(dsn string) (driver.Connector, error) {
+	c, err := parseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return NewConnector(c), nil
+}
+
+// NewConnector returns driver.Connector which uses c for every connection.
+func NewConnector(c *dynamodb.Client) driver.Connector {
+	return &connector{c}
+}
+
+type connector struct {
+	c *dynamodb.Client
+}
+
+func (c *connector) Connect(_ context.Context) (driver.Conn, error) {
+	return c.open(), nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return &Driver{}
+}
+
+func (c *connector) open() *conn {
+	return &conn{c: c.c}
+}
+
+func parseDSN(dsn string) (*dynamodb.Client, error) {
+	v, err := url.ParseQuery(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	var region dynamodb.Region
+	if name := v.Get("region"); name != "" {
+		r, ok := dynamodb.Regions[name]
+		if !ok {
+			r = dynamodb.NewRegion(name)
+		}
+		region = r
+	}
+	if endpoint := v.Get("endpoint"); endpoint != "" {
+		region.DynamoDBEndpoint = endpoint
+	}
+	if region.DynamoDBEndpoint == "" {
+		return nil, ErrInvalidRegion
+	}
+
+	c := &dynamodb.Client{
+		Region:            region,
+		DisableCRC32Check: v.Get("disable_crc32_check") == "true",
+	}
+	if v.Get("access_key") != "" || v.Get("secret_key") != "" {
+		c.Auth = dynamodb.Auth{AccessKey: v.Get("access_key"), SecretKey: v.Get("secret_key")}
+	} else {
+		c.Credentials = dynamodb.NewDefaultCredentials()
+	}
+	return c, nil
+}
@@ -0,0 +1,315 @@
+package sqldriver_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nabeken/goamz-dynamodb"
+	"github.com/nabeken/goamz-dynamodb/sqldriver"
+)
+
+type request struct {
+	Target        string
+	Authorization string
+	Body          map[string]interface{}
+}
+
+// newTestServer returns a server that responds with the responses in order
+// and records the requests.
+func newTestServer(t *testing.T, responses ...string) (*httptest.Server, *[]request) {
+	reqs := &[]request{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := request{Target: r.Header.Get("X-Amz-Target"), Authorization: r.Header.Get("Authorization")}
+		if err := json.Unmarshal(b, &req.Body); err != nil {
+			t.Fatal(err)
+		}
+		*reqs = append(*reqs, req)
+
+		if len(*reqs) > len(responses) {
+			t.Fatalf("unexpected request: %s", b)
+		}
+		w.Write([]byte(responses[len(*reqs)-1]))
+	}))
+	return ts, reqs
+}
+
+func openTestDB(ts *httptest.Server) *sql.DB {
+	return sql.OpenDB(sqldriver.NewConnector(&dynamodb.Client{
+		Auth:              dynamodb.Auth{AccessKey: "DUMMY_KEY", SecretKey: "DUMMY_SECRET"},
+		Region:            dynamodb.Region{Name: "us-east-1", DynamoDBEndpoint: ts.URL},
+		DisableCRC32Check: true,
+	}))
+}
+
+func TestQuery(t *testing.T) {
+	ts, reqs := newTestServer(t,
+		`{"Items":[{"HashKey":{"S":"HASH1"},"Attr":{"N":"1"}},{"HashKey":{"S":"HASH2"}}],"NextToken":"TOKEN"}`,
+		`{"Items":[{"HashKey":{"S":"HASH3"},"Attr":{"N":"3"}}]}`,
+	)
+	defer ts.Close()
+
+	db := openTestDB(ts)
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT * FROM "TABLE" WHERE Attr >= ?`, 1)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Attr", "HashKey"}, columns)
+
+	var hashKeys []string
+	var attrs []sql.NullInt64
+	for rows.Next() {
+		var hashKey string
+		var attr sql.NullInt64
+		if !assert.NoError(t, rows.Scan(&attr, &hashKey)) {
+			t.FailNow()
+		}
+		hashKeys = append(hashKeys, hashKey)
+		attrs = append(attrs, attr)
+	}
+	assert.NoError(t, rows.Err())
+	assert.Equal(t, []string{"HASH1", "HASH2", "HASH3"}, hashKeys)
+	assert.Equal(t, []sql.NullInt64{{Int64: 1, Valid: true}, {}, {Int64: 3, Valid: true}}, attrs)
+
+	if assert.Len(t, *reqs, 2) {
+		assert.Equal(t, "DynamoDB_20120810.ExecuteStatement", (*reqs)[0].Target)
+		assert.Equal(t, []interface{}{map[string]interface{}{"N": "1"}}, (*reqs)[0].Body["Parameters"])
+		assert.Nil(t, (*reqs)[0].Body["NextToken"])
+		assert.Equal(t, "TOKEN", (*reqs)[1].Body["NextToken"])
+	}
+}
+
+func TestQuery_EmptyFirstPage(t *testing.T) {
+	ts, reqs := newTestServer(t,
+		`{"Items":[],"NextToken":"TOKEN1"}`,
+		`{"NextToken":"TOKEN2"}`,
+		`{"Items":[{"HashKey":{"S":"HASH1"}}]}`,
+	)
+	defer ts.Close()
+
+	db := openTestDB(ts)
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT HashKey FROM "TABLE"`)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"HashKey"}, columns)
+
+	var hashKeys []string
+	for rows.Next() {
+		var hashKey string
+		if !assert.NoError(t, rows.Scan(&hashKey)) {
+			t.FailNow()
+		}
+		hashKeys = append(hashKeys, hashKey)
+	}
+	assert.NoError(t, rows.Err())
+	assert.Equal(t, []string{"HASH1"}, hashKeys)
+	if assert.Len(t, *reqs, 3) {
+		assert.Equal(t, "TOKEN1", (*reqs)[1].Body["NextToken"])
+		assert.Equal(t, "TOKEN2", (*reqs)[2].Body["NextToken"])
+	}
+}
+
+func TestQuery_UnknownColumn(t *testing.T) {
+	ts, _ := newTestServer(t,
+		`{"Items":[{"A":{"S":"A1"}}],"NextToken":"TOKEN"}`,
+		`{"Items":[{"A":{"S":"A2"},"B":{"S":"B2"}}]}`,
+	)
+	defer ts.Close()
+
+	db := openTestDB(ts)
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT * FROM "TABLE"`)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A"}, columns)
+
+	var as []string
+	for rows.Next() {
+		var a string
+		if !assert.NoError(t, rows.Scan(&a)) {
+			t.FailNow()
+		}
+		as = append(as, a)
+	}
+	// B in the second page must not be dropped silently
+	assert.True(t, errors.Is(rows.Err(), sqldriver.ErrUnknownColumn), "%v", rows.Err())
+	assert.Contains(t, rows.Err().Error(), "'B'")
+	assert.Equal(t, []string{"A1"}, as)
+}
+
+func TestQuery_Document(t *testing.T) {
+	ts, _ := newTestServer(t,
+		`{"Items":[{"Doc":{"M":{"Name":{"S":"NAME"},"Tags":{"L":[{"N":"1"},{"BOOL":true},{"NULL":true}]}}},"Set":{"SS":["A","B"]}}]}`,
+	)
+	defer ts.Close()
+
+	db := openTestDB(ts)
+	defer db.Close()
+
+	var doc, set string
+	if assert.NoError(t, db.QueryRow(`SELECT Doc, "Set" FROM "TABLE"`).Scan(&doc, &set)) {
+		assert.JSONEq(t, `{"Name":"NAME","Tags":["1",true,null]}`, doc)
+		assert.JSONEq(t, `["A","B"]`, set)
+	}
+}
+
+func TestExec(t *testing.T) {
+	ts, reqs := newTestServer(t, `{}`)
+	defer ts.Close()
+
+	db := openTestDB(ts)
+	defer db.Close()
+
+	_, err := db.Exec(`INSERT INTO "TABLE" VALUE {'HashKey': ?, 'Attr': ?}`, "HASH", 1.5)
+	assert.NoError(t, err)
+	if assert.Len(t, *reqs, 1) {
+		assert.Equal(t, `INSERT INTO "TABLE" VALUE {'HashKey': ?, 'Attr': ?}`, (*reqs)[0].Body["Statement"])
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"S": "HASH"},
+			map[string]interface{}{"N": "1.5"},
+		}, (*reqs)[0].Body["Parameters"])
+	}
+}
+
+func TestExec_Document(t *testing.T) {
+	ts, reqs := newTestServer(t, `{}`, `{}`)
+	defer ts.Close()
+
+	db := openTestDB(ts)
+	defer db.Close()
+
+	_, err := db.Exec(`UPDATE "TABLE" SET Doc = ? WHERE HashKey = ?`, map[string]dynamodb.AttributeValue{
+		"Name": dynamodb.NewString("NAME"),
+		"Tags": dynamodb.NewList(dynamodb.NewNumber(1)),
+	}, "HASH")
+	assert.NoError(t, err)
+
+	_, err = db.Exec(`UPDATE "TABLE" SET Tags = ? WHERE HashKey = ?`, []dynamodb.AttributeValue{
+		dynamodb.NewString("A"),
+		dynamodb.NewBool(true),
+	}, "HASH")
+	assert.NoError(t, err)
+
+	if assert.Len(t, *reqs, 2) {
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"M": map[string]interface{}{
+				"Name": map[string]interface{}{"S": "NAME"},
+				"Tags": map[string]interface{}{"L": []interface{}{map[string]interface{}{"N": "1"}}},
+			}},
+			map[string]interface{}{"S": "HASH"},
+		}, (*reqs)[0].Body["Parameters"])
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"L": []interface{}{
+				map[string]interface{}{"S": "A"},
+				map[string]interface{}{"BOOL": true},
+			}},
+			map[string]interface{}{"S": "HASH"},
+		}, (*reqs)[1].Body["Parameters"])
+	}
+}
+
+func TestTx(t *testing.T) {
+	ts, reqs := newTestServer(t, `{"Responses":[]}`)
+	defer ts.Close()
+
+	db := openTestDB(ts)
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, err = tx.Exec(`UPDATE "TABLE" SET Attr = ? WHERE HashKey = ?`, 1, "HASH1")
+	assert.NoError(t, err)
+	_, err = tx.Exec(`DELETE FROM "TABLE" WHERE HashKey = ?`, "HASH2")
+	assert.NoError(t, err)
+	_, err = tx.Query(`SELECT * FROM "TABLE"`)
+	assert.Equal(t, sqldriver.ErrQueryInTx, err)
+
+	// nothing is sent until Commit
+	assert.Len(t, *reqs, 0)
+	assert.NoError(t, tx.Commit())
+
+	if assert.Len(t, *reqs, 1) {
+		assert.Equal(t, "DynamoDB_20120810.ExecuteTransaction", (*reqs)[0].Target)
+		assert.Len(t, (*reqs)[0].Body["TransactStatements"], 2)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	_, err := sql.Open(sqldriver.DriverName, "endpoint=http://127.0.0.1:8000&access_key=DUMMY_KEY&secret_key=DUMMY_SECRET")
+	assert.NoError(t, err)
+
+	_, err = sql.Open(sqldriver.DriverName, "access_key=DUMMY_KEY&secret_key=DUMMY_SECRET")
+	assert.Equal(t, sqldriver.ErrInvalidRegion, err)
+}
+
+func TestOpen_NewRegion(t *testing.T) {
+	ts, reqs := newTestServer(t, `{}`)
+	defer ts.Close()
+
+	// a region unknown to dynamodb.Regions is signed with its name
+	db, err := sql.Open(sqldriver.DriverName, "region=xx-test-1&access_key=DUMMY_KEY&secret_key=DUMMY_SECRET&disable_crc32_check=true&endpoint="+url.QueryEscape(ts.URL))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`DELETE FROM "TABLE" WHERE HashKey = ?`, "HASH")
+	assert.NoError(t, err)
+	if assert.Len(t, *reqs, 1) {
+		assert.Contains(t, (*reqs)[0].Authorization, "/xx-test-1/dynamodb/")
+	}
+}
+
+func TestOpen_DefaultCredentials(t *testing.T) {
+	ts, reqs := newTestServer(t, `{}`)
+	defer ts.Close()
+
+	// credentials are retrieved on requests, not on Open
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_ACCESS_KEY", "")
+	db, err := sql.Open(sqldriver.DriverName, "disable_crc32_check=true&endpoint="+url.QueryEscape(ts.URL))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer db.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "ENV_KEY")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "ENV_SECRET")
+	_, err = db.Exec(`DELETE FROM "TABLE" WHERE HashKey = ?`, "HASH")
+	assert.NoError(t, err)
+	if assert.Len(t, *reqs, 1) {
+		assert.Contains(t, (*reqs)[0].Authorization, "Credential=ENV_KEY/")
+	}
+}
@@ -0,0 +1,162 @@
+package sqldriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/nabeken/goamz-dynamodb"
+)
+
+// rows fetches pages of ExecuteStatement lazily by following NextToken.
+//
+// Since items in DynamoDB have no fixed schema, columns are the sorted
+// attribute names found in the first non-empty page. Attributes missing in
+// an item are returned as NULL. database/sql cannot add columns while reading,
+// so Next fails with ErrUnknownColumn on an attribute found only in later pages.
+type rows struct {
+	ctx       context.Context
+	c         *dynamodb.Client
+	statement string
+	params    []dynamodb.AttributeValue
+
+	columns   []string
+	items     []map[string]dynamodb.AttributeValue
+	nextToken string
+}
+
+func newRows(ctx context.Context, c *dynamodb.Client, statement string, params []dynamodb.AttributeValue) (*rows, error) {
+	r := &rows{
+		ctx:       ctx,
+		c:         c,
+		statement: statement,
+		params:    params,
+	}
+	if err := r.fetch(nil); err != nil {
+		return nil, err
+	}
+	// PartiQL may return empty pages with NextToken before the first item
+	for len(r.items) == 0 && r.nextToken != "" {
+		if err := r.fetch(&dynamodb.ExecuteStatementOption{NextToken: r.nextToken}); err != nil {
+			return nil, err
+		}
+	}
+
+	names := map[string]struct{}{}
+	for i := range r.items {
+		for name := range r.items[i] {
+			names[name] = struct{}{}
+		}
+	}
+	for name := range names {
+		r.columns = append(r.columns, name)
+	}
+	sort.Strings(r.columns)
+	return r, nil
+}
+
+func (r *rows) fetch(eopt *dynamodb.ExecuteStatementOption) error {
+	ret, err := r.c.ExecuteStatementWithContext(r.ctx, r.statement, r.params, eopt)
+	if err != nil {
+		return err
+	}
+	r.items = ret.Items
+	r.nextToken = ret.NextToken
+	return nil
+}
+
+func (r *rows) Columns() []string {
+	return r.columns
+}
+
+func (r *rows) Close() error {
+	r.items = nil
+	r.nextToken = ""
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	// a page may be empty even if NextToken is returned
+	for len(r.items) == 0 {
+		if r.nextToken == "" {
+			return io.EOF
+		}
+		if err := r.fetch(&dynamodb.ExecuteStatementOption{NextToken: r.nextToken}); err != nil {
+			return err
+		}
+	}
+
+	item := r.items[0]
+	r.items = r.items[1:]
+	for name := range item {
+		if !r.hasColumn(name) {
+			return fmt.Errorf("%w: '%s'", ErrUnknownColumn, name)
+		}
+	}
+	for i, name := range r.columns {
+		av, ok := item[name]
+		if !ok {
+			dest[i] = nil
+			continue
+		}
+		v, err := driverValue(av)
+		if err != nil {
+			return err
+		}
+		dest[i] = v
+	}
+	return nil
+}
+
+func (r *rows) hasColumn(name string) bool {
+	i := sort.SearchStrings(r.columns, name)
+	return i < len(r.columns) && r.columns[i] == name
+}
+
+// driverValue converts AttributeValue into driver.Value.
+// Numbers are returned as string to keep their precision. Sets, maps and lists are encoded in JSON.
+func driverValue(av dynamodb.AttributeValue) (driver.Value, error) {
+	switch av.Type {
+	case dynamodb.TypeNull:
+		return nil, nil
+	case dynamodb.TypeBool:
+		return av.Data[0] == "true", nil
+	case dynamodb.TypeString, dynamodb.TypeNumber:
+		return string(av.Data[0]), nil
+	case dynamodb.TypeBinary:
+		return base64.StdEncoding.DecodeString(string(av.Data[0]))
+	}
+	return json.Marshal(jsonValue(av))
+}
+
+// jsonValue converts av into a value to encode in JSON without the type descriptors.
+func jsonValue(av dynamodb.AttributeValue) interface{} {
+	switch av.Type {
+	case dynamodb.TypeMap:
+		m := make(map[string]interface{}, len(av.Map))
+		for name, v := range av.Map {
+			m[name] = jsonValue(v)
+		}
+		return m
+	case dynamodb.TypeList:
+		l := make([]interface{}, len(av.List))
+		for i, v := range av.List {
+			l[i] = jsonValue(v)
+		}
+		return l
+	case dynamodb.TypeNull:
+		return nil
+	case dynamodb.TypeBool:
+		return len(av.Data) > 0 && av.Data[0] == "true"
+	case dynamodb.TypeString, dynamodb.TypeNumber, dynamodb.TypeBinary:
+		if len(av.Data) > 0 {
+			return string(av.Data[0])
+		}
+		return nil
+	}
+	return av.Data
+}
@@ -0,0 +1,232 @@
+package dynamodb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+type capacityKind int
+
+const (
+	capacityNone capacityKind = iota
+	capacityRead
+	capacityWrite
+	// capacityReadWrite is for PartiQL where the statement decides.
+	capacityReadWrite
+)
+
+// capacityKinds lists operations which consume capacity units.
+var capacityKinds = map[string]capacityKind{
+	"BatchGetItem":          capacityRead,
+	"GetItem":               capacityRead,
+	"Query":                 capacityRead,
+	"Scan":                  capacityRead,
+	"BatchWriteItem":        capacityWrite,
+	"DeleteItem":            capacityWrite,
+	"PutItem":               capacityWrite,
+	"UpdateItem":            capacityWrite,
+	"BatchExecuteStatement": capacityReadWrite,
+	"ExecuteStatement":      capacityReadWrite,
+	"ExecuteTransaction":    capacityReadWrite,
+}
+
+// ThroughputLimiter paces requests so that the capacity units consumed per table
+// stay around a target rate, for example a half of the provisioned throughput,
+// so that batch jobs never starve production traffic.
+//
+// When it is set to Client, ReturnConsumedCapacity is forced to TOTAL and
+// the ConsumedCapacity in every response is charged to the table.
+// A request waits while the table has consumed more than the target allows.
+// PartiQL requests are charged but never wait since their tables are known only from the response.
+// The zero value is ready to use.
+type ThroughputLimiter struct {
+	mu     sync.Mutex
+	tables map[string]*throughputTarget
+}
+
+type throughputTarget struct {
+	read  capacityBucket
+	write capacityBucket
+}
+
+// capacityBucket is a token bucket which may go into debt
+// since the cost of a request is known only after the response.
+type capacityBucket struct {
+	rate       float64
+	balance    float64
+	lastRefill time.Time
+}
+
+func NewThroughputLimiter() *ThroughputLimiter {
+	return &ThroughputLimiter{
+		tables: map[string]*throughputTarget{},
+	}
+}
+
+// SetTarget sets the target rate of table in capacity units per second.
+// Zero means unlimited. Tables without a target are not limited.
+func (l *ThroughputLimiter) SetTarget(table string, readUnits, writeUnits float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.tables == nil {
+		l.tables = map[string]*throughputTarget{}
+	}
+	now := time.Now()
+	l.tables[table] = &throughputTarget{
+		read:  capacityBucket{rate: readUnits, balance: math.Max(1, readUnits), lastRefill: now},
+		write: capacityBucket{rate: writeUnits, balance: math.Max(1, writeUnits), lastRefill: now},
+	}
+}
+
+// SetTargetFromTable sets the target rate of table to ratio of its provisioned throughput read via DescribeTable.
+func (l *ThroughputLimiter) SetTargetFromTable(ctx context.Context, c *Client, table string, ratio float64) error {
+	ret, err := c.DescribeTableWithContext(ctx, table)
+	if err != nil {
+		return err
+	}
+	pt := ret.Table.ProvisionedThroughput
+	l.SetTarget(table, float64(pt.ReadCapacityUnits)*ratio, float64(pt.WriteCapacityUnits)*ratio)
+	return nil
+}
+
+// Wait blocks until table has capacity for the operation or ctx is done.
+func (l *ThroughputLimiter) Wait(ctx context.Context, table string, operation string) error {
+	kind := capacityKinds[operation]
+	if kind == capacityNone {
+		return nil
+	}
+	for {
+		d := l.reserve(table, kind)
+		if d == 0 {
+			return nil
+		}
+		if err := sleep(ctx, d); err != nil {
+			return err
+		}
+	}
+}
+
+// reserve returns how long a request has to wait.
+func (l *ThroughputLimiter) reserve(table string, kind capacityKind) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	t, ok := l.tables[table]
+	if !ok {
+		return 0
+	}
+	now := time.Now()
+	var d time.Duration
+	if kind != capacityWrite {
+		d = maxDuration(d, t.read.wait(now))
+	}
+	if kind != capacityRead {
+		d = maxDuration(d, t.write.wait(now))
+	}
+	return d
+}
+
+// Consume charges the capacity units consumed by an operation.
+func (l *ThroughputLimiter) Consume(operation string, cc ConsumedCapacity) {
+	kind := capacityKinds[operation]
+	if kind == capacityNone {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	t, ok := l.tables[cc.TableName]
+	if !ok {
+		return
+	}
+	read, write := cc.ReadCapacityUnits, cc.WriteCapacityUnits
+	if read == 0 && write == 0 {
+		// only CapacityUnits is returned for most operations
+		if kind == capacityRead {
+			read = cc.CapacityUnits
+		} else {
+			write = cc.CapacityUnits
+		}
+	}
+	now := time.Now()
+	t.read.consume(now, read)
+	t.write.consume(now, write)
+}
+
+func (b *capacityBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.lastRefill).Seconds()
+	b.lastRefill = now
+	if elapsed > 0 {
+		b.balance = math.Min(math.Max(1, b.rate), b.balance+elapsed*b.rate)
+	}
+}
+
+func (b *capacityBucket) wait(now time.Time) time.Duration {
+	if b.rate == 0 {
+		return 0
+	}
+	b.refill(now)
+	if b.balance >= 0 {
+		return 0
+	}
+	return time.Duration(-b.balance / b.rate * float64(time.Second))
+}
+
+func (b *capacityBucket) consume(now time.Time, units float64) {
+	if b.rate == 0 || units == 0 {
+		return
+	}
+	b.refill(now)
+	b.balance -= units
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// forceConsumedCapacity sets ReturnConsumedCapacity to TOTAL in the JSON-encoded request
+// unless the request already asks for it.
+func forceConsumedCapacity(j []byte) ([]byte, error) {
+	param := map[string]json.RawMessage{}
+	if err := json.Unmarshal(j, &param); err != nil {
+		return nil, err
+	}
+	if v, ok := param["ReturnConsumedCapacity"]; ok && !bytes.Equal(v, []byte(`"NONE"`)) {
+		return j, nil
+	}
+	param["ReturnConsumedCapacity"] = json.RawMessage(`"` + ConsumedCapTotal + `"`)
+	return json.Marshal(param)
+}
+
+// consumedCapacities extracts ConsumedCapacity from a response
+// which is a single object or a list depending on the operation.
+func consumedCapacities(body []byte) []ConsumedCapacity {
+	ret := struct {
+		ConsumedCapacity json.RawMessage
+	}{}
+	if err := json.Unmarshal(body, &ret); err != nil || len(ret.ConsumedCapacity) == 0 {
+		return nil
+	}
+	if strings.HasPrefix(string(ret.ConsumedCapacity), "[") {
+		var ccs []ConsumedCapacity
+		if err := json.Unmarshal(ret.ConsumedCapacity, &ccs); err != nil {
+			return nil
+		}
+		return ccs
+	}
+	var cc ConsumedCapacity
+	if err := json.Unmarshal(ret.ConsumedCapacity, &cc); err != nil {
+		return nil
+	}
+	return []ConsumedCapacity{cc}
+}
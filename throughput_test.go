@@ -0,0 +1,147 @@
+package dynamodb_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nabeken/goamz-dynamodb"
+	"github.com/nabeken/goamz-dynamodb/dynamodbtest"
+)
+
+func TestThroughputLimiter(t *testing.T) {
+	var returnConsumedCapacity []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		req := map[string]interface{}{}
+		json.Unmarshal(body, &req)
+		rcc, _ := req["ReturnConsumedCapacity"].(string)
+		returnConsumedCapacity = append(returnConsumedCapacity, rcc)
+
+		switch r.Header.Get("X-Amz-Target") {
+		case "DynamoDB_20120810.DescribeTable":
+			w.Write([]byte(`{"Table":{"TableName":"TABLE","ProvisionedThroughput":{"ReadCapacityUnits":2000,"WriteCapacityUnits":2000}}}`))
+		default:
+			w.Write([]byte(`{"ConsumedCapacity":{"TableName":"TABLE","CapacityUnits":100}}`))
+		}
+	}))
+	defer ts.Close()
+
+	l := dynamodb.NewThroughputLimiter()
+	c := &dynamodb.Client{
+		Auth:              dummyAuth,
+		Region:            dynamodb.Region{DynamoDBEndpoint: ts.URL},
+		DisableCRC32Check: true,
+		ThroughputLimiter: l,
+	}
+	// 1000 WCU is a half of the provisioned throughput
+	if !assert.NoError(t, l.SetTargetFromTable(context.Background(), c, "TABLE", 0.5)) {
+		t.FailNow()
+	}
+
+	item := dynamodb.Item{"HashKey": dynamodb.NewString("HASH")}
+
+	// a second worth of capacity units is available as a burst
+	start := time.Now()
+	for i := 0; i < 11; i++ {
+		_, err := c.PutItem("TABLE", item, nil)
+		assert.NoError(t, err)
+	}
+	assert.True(t, time.Since(start) < 50*time.Millisecond, "burst must not be limited")
+
+	start = time.Now()
+	_, err := c.PutItem("TABLE", item, &dynamodb.PutItemOption{ReturnConsumedCapacity: dynamodb.ConsumedCapIndexes})
+	assert.NoError(t, err)
+	assert.True(t, time.Since(start) >= 80*time.Millisecond, "request must wait for consumed capacity units to be refilled")
+
+	// reads are limited separately
+	start = time.Now()
+	_, err = c.GetItem("TABLE", item, nil)
+	assert.NoError(t, err)
+	assert.True(t, time.Since(start) < 50*time.Millisecond)
+
+	if assert.Len(t, returnConsumedCapacity, 14) {
+		assert.Equal(t, "", returnConsumedCapacity[0], "DescribeTable must not be modified")
+		assert.Equal(t, "TOTAL", returnConsumedCapacity[1])
+		assert.Equal(t, "INDEXES", returnConsumedCapacity[12])
+		assert.Equal(t, "TOTAL", returnConsumedCapacity[13])
+	}
+}
+
+func TestThroughputLimiter_Batch(t *testing.T) {
+	srv := dynamodbtest.NewServer()
+	defer srv.Close()
+
+	l := dynamodb.NewThroughputLimiter()
+	c := srv.NewClient()
+	c.ThroughputLimiter = l
+	_, err := c.CreateTable(&dynamodb.Table{
+		Name:                  "TABLE",
+		AttributeDefinitions:  []dynamodb.AttributeDefinition{{Name: "HashKey", Type: dynamodb.TypeString}},
+		KeySchema:             []dynamodb.KeySchemaElement{{AttributeName: "HashKey", KeyType: dynamodb.KeyTypeHash}},
+		ProvisionedThroughput: dynamodb.ProvisionedThroughput{ReadCapacityUnits: 20, WriteCapacityUnits: 20},
+	}, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	l.SetTarget("TABLE", 20, 20)
+
+	var writes []dynamodb.WriteRequest
+	var keys []map[string]dynamodb.AttributeValue
+	for i := 0; i < 25; i++ {
+		key := dynamodb.Item{"HashKey": dynamodb.NewString(strconv.Itoa(i))}
+		writes = append(writes, dynamodb.WriteRequest{PutRequest: dynamodb.PutRequest{Item: key}})
+		keys = append(keys, key)
+	}
+
+	wret, err := c.BatchWriteItem(map[string][]dynamodb.WriteRequest{"TABLE": writes}, nil)
+	if assert.NoError(t, err) && assert.Len(t, wret.ConsumedCapacity, 1) {
+		assert.Equal(t, "TABLE", wret.ConsumedCapacity[0].TableName)
+		assert.Equal(t, 25.0, wret.ConsumedCapacity[0].CapacityUnits)
+	}
+
+	gret, err := c.BatchGetItem(map[string]dynamodb.KeysAndAttributes{"TABLE": {Keys: keys}}, nil)
+	if assert.NoError(t, err) && assert.Len(t, gret.ConsumedCapacity, 1) {
+		assert.Equal(t, "TABLE", gret.ConsumedCapacity[0].TableName)
+		assert.Len(t, gret.Responses["TABLE"], 25)
+	}
+
+	// 25 WCU are charged to the target of 20 WCU/s
+	start := time.Now()
+	_, err = c.PutItem("TABLE", dynamodb.Item{"HashKey": dynamodb.NewString("HASH")}, nil)
+	assert.NoError(t, err)
+	assert.True(t, time.Since(start) >= 200*time.Millisecond, "request must wait for consumed capacity units to be refilled")
+}
+
+func TestThroughputLimiter_ZeroValue(t *testing.T) {
+	var l dynamodb.ThroughputLimiter
+	l.Consume("PutItem", dynamodb.ConsumedCapacity{TableName: "TABLE", CapacityUnits: 1000})
+	assert.NoError(t, l.Wait(context.Background(), "TABLE", "PutItem"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	l.SetTarget("TABLE", 1, 1)
+	l.Consume("PutItem", dynamodb.ConsumedCapacity{TableName: "TABLE", CapacityUnits: 1000})
+	assert.Equal(t, context.DeadlineExceeded, l.Wait(ctx, "TABLE", "PutItem"))
+}
+
+func TestThroughputLimiter_NoTarget(t *testing.T) {
+	l := dynamodb.NewThroughputLimiter()
+	l.Consume("PutItem", dynamodb.ConsumedCapacity{TableName: "TABLE", CapacityUnits: 1000})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.NoError(t, l.Wait(ctx, "TABLE", "PutItem"))
+
+	l.SetTarget("TABLE", 0, 1)
+	l.Consume("PutItem", dynamodb.ConsumedCapacity{TableName: "TABLE", CapacityUnits: 1000})
+	assert.NoError(t, l.Wait(ctx, "TABLE", "GetItem"), "zero target must not be limited")
+	assert.Equal(t, context.DeadlineExceeded, l.Wait(ctx, "TABLE", "PutItem"))
+}
@@ -0,0 +1,73 @@
+package dynamodb
+
+import (
+	"context"
+	"strings"
+)
+
+// Span attribute keys set by Client.
+const (
+	AttrTable            = "dynamodb.table"
+	AttrTarget           = "dynamodb.target"
+	AttrAttempt          = "dynamodb.attempt"
+	AttrRetries          = "dynamodb.retries"
+	AttrRequestID        = "dynamodb.request_id"
+	AttrStatusCode       = "http.status_code"
+	AttrConsumedCapacity = "dynamodb.consumed_capacity"
+	AttrErrorCode        = "dynamodb.error_code"
+)
+
+// Tracer starts spans around operations of Client.
+// Client starts a span per operation and a child span per attempt.
+type Tracer interface {
+	// StartSpan starts a span as a child of the span in ctx if any.
+	// The returned context carries the new span.
+	StartSpan(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a unit of work started by Tracer.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	// SetError records err which ended the span.
+	SetError(err error)
+	End()
+}
+
+func (c *Client) startOperationSpan(ctx context.Context, name string, tables []string) (context.Context, Span) {
+	ctx, span := c.Tracer.StartSpan(ctx, "DynamoDB."+name)
+	span.SetAttribute(AttrTarget, target(name))
+	span.SetAttribute(AttrTable, strings.Join(tables, ","))
+	return ctx, span
+}
+
+func (c *Client) startAttemptSpan(ctx context.Context, name string, attempt int) (context.Context, Span) {
+	ctx, span := c.Tracer.StartSpan(ctx, "DynamoDB."+name+".Attempt")
+	span.SetAttribute(AttrTarget, target(name))
+	span.SetAttribute(AttrAttempt, attempt)
+	return ctx, span
+}
+
+// endSpan sets attributes from r and err to span and ends it.
+func endSpan(span Span, r *Request, err error) {
+	if r != nil && r.HTTPResponse != nil {
+		span.SetAttribute(AttrStatusCode, r.HTTPResponse.StatusCode)
+		if id := r.HTTPResponse.Header.Get("x-amzn-RequestId"); id != "" {
+			span.SetAttribute(AttrRequestID, id)
+		}
+	}
+	if err != nil {
+		if code := ErrorCode(err); code != "" {
+			span.SetAttribute(AttrErrorCode, code)
+		}
+		span.SetError(err)
+	} else if r != nil {
+		if ccs := consumedCapacities(r.ResponseBody); len(ccs) > 0 {
+			var units float64
+			for _, cc := range ccs {
+				units += cc.CapacityUnits
+			}
+			span.SetAttribute(AttrConsumedCapacity, units)
+		}
+	}
+	span.End()
+}
@@ -0,0 +1,103 @@
+package dynamodb_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nabeken/goamz-dynamodb"
+)
+
+type testSpan struct {
+	name   string
+	parent *testSpan
+	attrs  map[string]interface{}
+	err    error
+	ended  bool
+}
+
+func (s *testSpan) SetAttribute(key string, value interface{}) { s.attrs[key] = value }
+func (s *testSpan) SetError(err error)                         { s.err = err }
+func (s *testSpan) End()                                       { s.ended = true }
+
+type spanKey struct{}
+
+type testTracer struct {
+	mu    sync.Mutex
+	spans []*testSpan
+}
+
+func (t *testTracer) StartSpan(ctx context.Context, name string) (context.Context, dynamodb.Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	parent, _ := ctx.Value(spanKey{}).(*testSpan)
+	span := &testSpan{name: name, parent: parent, attrs: map[string]interface{}{}}
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func TestTracer(t *testing.T) {
+	var n int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		w.Header().Set("x-amzn-RequestId", "REQ"+string(rune('0'+n)))
+		if n == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"__type":"ServiceUnavailable","message":"unavailable"}`))
+			return
+		}
+		w.Write([]byte(`{"ConsumedCapacity":{"TableName":"TABLE","CapacityUnits":1}}`))
+	}))
+	defer ts.Close()
+
+	tracer := &testTracer{}
+	c := &dynamodb.Client{
+		Auth:              dummyAuth,
+		Region:            dynamodb.Region{DynamoDBEndpoint: ts.URL},
+		DisableCRC32Check: true,
+		Retryer:           dynamodb.DefaultRetryer{MinRetryDelay: time.Millisecond, MaxRetryDelay: time.Millisecond},
+		Tracer:            tracer,
+	}
+
+	parent, _ := tracer.StartSpan(context.Background(), "parent")
+	_, err := c.PutItemWithContext(parent, "TABLE", dynamodb.Item{"ID": dynamodb.NewString("1")}, nil)
+	assert.NoError(t, err)
+
+	if !assert.Len(t, tracer.spans, 4) {
+		return
+	}
+	op, first, second := tracer.spans[1], tracer.spans[2], tracer.spans[3]
+
+	assert.Equal(t, "DynamoDB.PutItem", op.name)
+	assert.Equal(t, tracer.spans[0], op.parent)
+	assert.True(t, op.ended)
+	assert.NoError(t, op.err)
+	assert.Equal(t, map[string]interface{}{
+		dynamodb.AttrTable:            "TABLE",
+		dynamodb.AttrTarget:           "DynamoDB_20120810.PutItem",
+		dynamodb.AttrRetries:          1,
+		dynamodb.AttrStatusCode:       200,
+		dynamodb.AttrRequestID:        "REQ2",
+		dynamodb.AttrConsumedCapacity: 1.0,
+	}, op.attrs)
+
+	assert.Equal(t, "DynamoDB.PutItem.Attempt", first.name)
+	assert.Equal(t, op, first.parent)
+	assert.True(t, first.ended)
+	assert.Error(t, first.err)
+	assert.Equal(t, 0, first.attrs[dynamodb.AttrAttempt])
+	assert.Equal(t, 503, first.attrs[dynamodb.AttrStatusCode])
+	assert.Equal(t, "REQ1", first.attrs[dynamodb.AttrRequestID])
+	assert.Equal(t, "ServiceUnavailable", first.attrs[dynamodb.AttrErrorCode])
+
+	assert.Equal(t, op, second.parent)
+	assert.True(t, second.ended)
+	assert.NoError(t, second.err)
+	assert.Equal(t, 1, second.attrs[dynamodb.AttrAttempt])
+	assert.Equal(t, 1.0, second.attrs[dynamodb.AttrConsumedCapacity])
+}
@@ -1,9 +1,11 @@
 package dynamodb
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"strconv"
 )
 
@@ -44,10 +46,17 @@ const (
 	TypeStringSet AttributeType = "SS"
 	TypeNumberSet AttributeType = "NS"
 	TypeBinarySet AttributeType = "BS"
+
+	TypeBool AttributeType = "BOOL"
+	TypeNull AttributeType = "NULL"
+
+	TypeMap  AttributeType = "M"
+	TypeList AttributeType = "L"
 )
 
 const (
 	CmpOpEQ ComparisonOperator = "EQ"
+	CmpOpNE ComparisonOperator = "NE"
 
 	CmpOpLE ComparisonOperator = "LE"
 	CmpOpLT ComparisonOperator = "LT"
@@ -140,12 +149,21 @@ type AttributeDefinition struct {
 	Type AttributeType `json:"AttributeType"`
 }
 
+// AttributeValue holds a value in Data, or in Map and List if Type is TypeMap and TypeList.
 type AttributeValue struct {
 	Type AttributeType
 	Data []AttributeData
+	Map  map[string]AttributeValue
+	List []AttributeValue
 }
 
 func (v AttributeValue) MarshalJSON() ([]byte, error) {
+	switch v.Type {
+	case TypeString, TypeNumber, TypeBinary, TypeBool:
+		if len(v.Data) == 0 {
+			return nil, fmt.Errorf("dynamodb: no data in %s attribute value", v.Type)
+		}
+	}
 	switch v.Type {
 	case TypeString:
 		return json.Marshal(stringAttributeValue{v.Data[0]})
@@ -160,6 +178,26 @@ func (v AttributeValue) MarshalJSON() ([]byte, error) {
 	case TypeBinarySet:
 		// TODO: encoding with base64
 		return json.Marshal(binarySetAttributeValue{v.Data})
+	case TypeBool:
+		b, err := strconv.ParseBool(string(v.Data[0]))
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(boolAttributeValue{b})
+	case TypeNull:
+		return json.Marshal(nullAttributeValue{true})
+	case TypeMap:
+		m := v.Map
+		if m == nil {
+			m = map[string]AttributeValue{}
+		}
+		return json.Marshal(mapAttributeValue{m})
+	case TypeList:
+		l := v.List
+		if l == nil {
+			l = []AttributeValue{}
+		}
+		return json.Marshal(listAttributeValue{l})
 	}
 	return nil, fmt.Errorf("dynamodb: failed to marshal '%v'", v)
 }
@@ -167,7 +205,7 @@ func (v AttributeValue) MarshalJSON() ([]byte, error) {
 func (v *AttributeValue) UnmarshalJSON(data []byte) error {
 	// {"SS":"ABC"}
 	// {"SS":["ABC"]}
-	j := map[AttributeType]interface{}{}
+	j := map[AttributeType]json.RawMessage{}
 	jerr := json.Unmarshal(data, &j)
 	if jerr != nil {
 		return nil
@@ -175,18 +213,38 @@ func (v *AttributeValue) UnmarshalJSON(data []byte) error {
 	if len(j) != 1 {
 		return errors.New("dynamodb: failed to decode to AttributeValue")
 	}
-	for at := range j {
+	for at, raw := range j {
 		v.Type = at
+		switch at {
+		case TypeMap:
+			return json.Unmarshal(raw, &v.Map)
+		case TypeList:
+			return json.Unmarshal(raw, &v.List)
+		}
+
+		var jd interface{}
+		if err := json.Unmarshal(raw, &jd); err != nil {
+			return err
+		}
 		// TODO: decoding with base64
-		if v.Type.IsSet() {
+		switch d := jd.(type) {
+		case []interface{}:
 			// j[at] = ["ABC"]
-			set := j[at].([]interface{})
-			for i := range set {
-				v.Data = append(v.Data, AttributeData(set[i].(string)))
+			for i := range d {
+				sd, ok := d[i].(string)
+				if !ok {
+					return fmt.Errorf("dynamodb: failed to decode '%v' in %s", d[i], at)
+				}
+				v.Data = append(v.Data, AttributeData(sd))
 			}
-		} else {
+		case string:
 			// j[at] = "ABC"
-			v.Data = append(v.Data, AttributeData(j[at].(string)))
+			v.Data = append(v.Data, AttributeData(d))
+		case bool:
+			// j[at] = true
+			v.Data = append(v.Data, AttributeData(strconv.FormatBool(d)))
+		default:
+			return fmt.Errorf("dynamodb: unsupported attribute type %s", at)
 		}
 	}
 	return nil
@@ -218,20 +276,39 @@ func (au AttributeUpdate) MarshalJSON() ([]byte, error) {
 	)
 }
 
+type BatchExecuteStatementResult struct {
+	ConsumedCapacity []ConsumedCapacity       `json:",omitempty"`
+	Responses        []BatchStatementResponse `json:",omitempty"`
+}
+
 type BatchGetItemResult struct {
-	ConsumedCapacity ConsumedCapacity `json:",omitempty"`
+	ConsumedCapacity []ConsumedCapacity `json:",omitempty"`
 	Responses        map[string][]map[string]AttributeValue
 	UnprocessedKeys  map[string]KeysAndAttributes
 }
 
+type BatchStatementError struct {
+	Code    string
+	Item    map[string]AttributeValue `json:",omitempty"`
+	Message string
+}
+
+type BatchStatementResponse struct {
+	Error     *BatchStatementError      `json:",omitempty"`
+	Item      map[string]AttributeValue `json:",omitempty"`
+	TableName string                    `json:",omitempty"`
+}
+
 type BatchWriteItemResult struct {
-	ConsumedCapacity      ConsumedCapacity `json:",omitempty"`
+	ConsumedCapacity      []ConsumedCapacity `json:",omitempty"`
 	ItemCollectionMetrics map[string][]ItemCollectionMetrics
 	UnprocessedItems      map[string][]WriteRequest
 }
 
 type Capacity struct {
-	CapacityUnits float64 `json:",omitempty"`
+	CapacityUnits      float64 `json:",omitempty"`
+	ReadCapacityUnits  float64 `json:",omitempty"`
+	WriteCapacityUnits float64 `json:",omitempty"`
 }
 
 type Condition struct {
@@ -243,8 +320,10 @@ type ConsumedCapacity struct {
 	CapacityUnits          float64             `json:",omitempty"`
 	GlobalSecondaryIndexes map[string]Capacity `json:",omitempty"`
 	LocalSecondaryIndexes  map[string]Capacity `json:",omitempty"`
+	ReadCapacityUnits      float64             `json:",omitempty"`
 	Table                  Capacity            `json:",omitempty"`
 	TableName              string
+	WriteCapacityUnits     float64 `json:",omitempty"`
 }
 
 type UpdateItemResult struct {
@@ -267,10 +346,29 @@ type DeleteItemResult struct {
 	ItemCollectionMetrics ItemCollectionMetrics
 }
 
+type DescribeLimitsResult struct {
+	AccountMaxReadCapacityUnits  int64
+	AccountMaxWriteCapacityUnits int64
+	TableMaxReadCapacityUnits    int64
+	TableMaxWriteCapacityUnits   int64
+}
+
 type DescribeTableResult struct {
 	Table TableDescription `json:",omitempty"`
 }
 
+type ExecuteStatementResult struct {
+	ConsumedCapacity ConsumedCapacity            `json:",omitempty"`
+	Items            []map[string]AttributeValue `json:",omitempty"`
+	LastEvaluatedKey map[string]AttributeValue   `json:",omitempty"`
+	NextToken        string                      `json:",omitempty"`
+}
+
+type ExecuteTransactionResult struct {
+	ConsumedCapacity []ConsumedCapacity `json:",omitempty"`
+	Responses        []ItemResponse     `json:",omitempty"`
+}
+
 type GetItemResult struct {
 	ConsumedCapacity ConsumedCapacity
 	Item             map[string]AttributeValue
@@ -307,6 +405,10 @@ type ItemCollectionMetrics struct {
 	SizeEstimateRangeGB float64
 }
 
+type ItemResponse struct {
+	Item map[string]AttributeValue `json:",omitempty"`
+}
+
 type KeySchemaElement struct {
 	AttributeName string
 	KeyType       KeyType
@@ -323,6 +425,11 @@ type ListTablesResult struct {
 	TableNames             []string `json:",omitempty"`
 }
 
+type ListTagsOfResourceResult struct {
+	NextToken string `json:",omitempty"`
+	Tags      []Tag  `json:",omitempty"`
+}
+
 type LocalSecondaryIndex struct {
 	IndexName  string
 	KeySchema  []KeySchemaElement
@@ -387,6 +494,12 @@ type Table struct {
 type TableOption struct {
 	GlobalSecondaryIndexes []GlobalSecondaryIndex `json:",omitempty"`
 	LocalSecondaryIndexes  []LocalSecondaryIndex  `json:",omitempty"`
+	Tags                   []Tag                  `json:",omitempty"`
+}
+
+type Tag struct {
+	Key   string
+	Value string
 }
 
 type TableDescription struct {
@@ -398,6 +511,7 @@ type TableDescription struct {
 	KeySchema              []KeySchemaElement                `json:",omitempty"`
 	LocalSecondaryIndexes  []LocalSecondaryIndexDescription  `json:",omitempty"`
 	ProvisionedThroughput  ProvisionedThroughputDescription  `json:",omitempty"`
+	TableArn               string                            `json:",omitempty"`
 	TableName              string                            `json:",omitempty"`
 	TableSizeBytes         int64                             `json:",omitempty"`
 	TableStatus            TableStatus                       `json:",omitempty"`
@@ -447,6 +561,124 @@ func NewNumberSet(val ...int) AttributeValue {
 	}
 }
 
+func NewBool(val bool) AttributeValue {
+	return AttributeValue{
+		Type: TypeBool,
+		Data: []AttributeData{
+			AttributeData(strconv.FormatBool(val)),
+		},
+	}
+}
+
+func NewNull() AttributeValue {
+	return AttributeValue{
+		Type: TypeNull,
+		Data: []AttributeData{
+			AttributeData("true"),
+		},
+	}
+}
+
+func NewMap(val map[string]AttributeValue) AttributeValue {
+	return AttributeValue{
+		Type: TypeMap,
+		Map:  val,
+	}
+}
+
+func NewList(val ...AttributeValue) AttributeValue {
+	return AttributeValue{
+		Type: TypeList,
+		List: val,
+	}
+}
+
+// NewAttributeValue converts a Go value into AttributeValue.
+// Supported values are AttributeValue, string, []byte, bool, nil,
+// integers, floats, []string, []int, map[string]AttributeValue and []AttributeValue.
+// []byte is encoded with base64.
+func NewAttributeValue(val interface{}) (AttributeValue, error) {
+	switch v := val.(type) {
+	case AttributeValue:
+		return v, nil
+	case nil:
+		return NewNull(), nil
+	case string:
+		return NewString(v), nil
+	case []byte:
+		return AttributeValue{
+			Type: TypeBinary,
+			Data: []AttributeData{
+				AttributeData(base64.StdEncoding.EncodeToString(v)),
+			},
+		}, nil
+	case bool:
+		return NewBool(v), nil
+	case int:
+		return NewNumber(v), nil
+	case int8:
+		return newNumberFromString(strconv.FormatInt(int64(v), 10)), nil
+	case int16:
+		return newNumberFromString(strconv.FormatInt(int64(v), 10)), nil
+	case int32:
+		return newNumberFromString(strconv.FormatInt(int64(v), 10)), nil
+	case int64:
+		return newNumberFromString(strconv.FormatInt(v, 10)), nil
+	case uint:
+		return newNumberFromString(strconv.FormatUint(uint64(v), 10)), nil
+	case uint8:
+		return newNumberFromString(strconv.FormatUint(uint64(v), 10)), nil
+	case uint16:
+		return newNumberFromString(strconv.FormatUint(uint64(v), 10)), nil
+	case uint32:
+		return newNumberFromString(strconv.FormatUint(uint64(v), 10)), nil
+	case uint64:
+		return newNumberFromString(strconv.FormatUint(v, 10)), nil
+	case float32:
+		return newNumberFromFloat(float64(v), 32)
+	case float64:
+		return newNumberFromFloat(v, 64)
+	case []string:
+		return NewStringSet(v...), nil
+	case []int:
+		return NewNumberSet(v...), nil
+	case map[string]AttributeValue:
+		return NewMap(v), nil
+	case []AttributeValue:
+		return NewList(v...), nil
+	}
+	return AttributeValue{}, fmt.Errorf("dynamodb: unsupported type %T for AttributeValue", val)
+}
+
+// NewParameters converts Go values into parameters for PartiQL statements.
+func NewParameters(vals ...interface{}) ([]AttributeValue, error) {
+	params := make([]AttributeValue, len(vals))
+	for i := range vals {
+		av, err := NewAttributeValue(vals[i])
+		if err != nil {
+			return nil, err
+		}
+		params[i] = av
+	}
+	return params, nil
+}
+
+func newNumberFromString(val string) AttributeValue {
+	return AttributeValue{
+		Type: TypeNumber,
+		Data: []AttributeData{
+			AttributeData(val),
+		},
+	}
+}
+
+func newNumberFromFloat(val float64, bitSize int) (AttributeValue, error) {
+	if math.IsNaN(val) || math.IsInf(val, 0) {
+		return AttributeValue{}, fmt.Errorf("dynamodb: %v is not a valid number", val)
+	}
+	return newNumberFromString(strconv.FormatFloat(val, 'f', -1, bitSize)), nil
+}
+
 // Just for JSON-transport
 type stringAttributeValue struct {
 	S AttributeData
@@ -471,3 +703,19 @@ type numberSetAttributeValue struct {
 type binarySetAttributeValue struct {
 	BS []AttributeData
 }
+
+type boolAttributeValue struct {
+	BOOL bool
+}
+
+type mapAttributeValue struct {
+	M map[string]AttributeValue
+}
+
+type listAttributeValue struct {
+	L []AttributeValue
+}
+
+type nullAttributeValue struct {
+	NULL bool
+}
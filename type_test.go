@@ -55,3 +55,80 @@ func TestAttributeValue_NumberSet(t *testing.T) {
 	assert.NoError(t, jerr)
 	assert.Equal(t, &av, nav)
 }
+
+func TestAttributeValue_Bool(t *testing.T) {
+	av := dynamodb.NewBool(true)
+	j, jerr := json.Marshal(&av)
+	assert.NoError(t, jerr)
+	assert.Equal(t, `{"BOOL":true}`, string(j))
+
+	nav := &dynamodb.AttributeValue{}
+	jerr = json.Unmarshal(j, nav)
+	assert.NoError(t, jerr)
+	assert.Equal(t, &av, nav)
+}
+
+func TestAttributeValue_Null(t *testing.T) {
+	av := dynamodb.NewNull()
+	j, jerr := json.Marshal(&av)
+	assert.NoError(t, jerr)
+	assert.Equal(t, `{"NULL":true}`, string(j))
+
+	nav := &dynamodb.AttributeValue{}
+	jerr = json.Unmarshal(j, nav)
+	assert.NoError(t, jerr)
+	assert.Equal(t, &av, nav)
+}
+
+func TestAttributeValue_Map(t *testing.T) {
+	av := dynamodb.NewMap(map[string]dynamodb.AttributeValue{
+		"Name": dynamodb.NewString("STRING"),
+		"Tags": dynamodb.NewList(dynamodb.NewNumber(1), dynamodb.NewMap(nil)),
+	})
+	j, jerr := json.Marshal(&av)
+	assert.NoError(t, jerr)
+	assert.Equal(t, `{"M":{"Name":{"S":"STRING"},"Tags":{"L":[{"N":"1"},{"M":{}}]}}}`, string(j))
+
+	nav := &dynamodb.AttributeValue{}
+	jerr = json.Unmarshal(j, nav)
+	assert.NoError(t, jerr)
+	assert.Equal(t, "STRING", string(nav.Map["Name"].Data[0]))
+	assert.Equal(t, dynamodb.NewNumber(1), nav.Map["Tags"].List[0])
+	assert.Equal(t, dynamodb.TypeMap, nav.Map["Tags"].List[1].Type)
+	assert.Empty(t, nav.Map["Tags"].List[1].Map)
+}
+
+func TestAttributeValue_Unsupported(t *testing.T) {
+	nav := &dynamodb.AttributeValue{}
+	assert.Error(t, json.Unmarshal([]byte(`{"N":1}`), nav))
+
+	for _, typ := range []dynamodb.AttributeType{dynamodb.TypeString, dynamodb.TypeNumber, dynamodb.TypeBinary, dynamodb.TypeBool} {
+		_, err := json.Marshal(dynamodb.AttributeValue{Type: typ})
+		assert.Error(t, err, typ)
+	}
+}
+
+func TestNewParameters(t *testing.T) {
+	params, err := dynamodb.NewParameters(
+		"STRING",
+		123,
+		int64(-123),
+		uint8(8),
+		1.5,
+		true,
+		nil,
+		[]byte("BINARY"),
+		[]string{"STRING1", "STRING2"},
+		[]int{1, 2},
+		dynamodb.NewString("ATTR"),
+	)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	j, jerr := json.Marshal(params)
+	assert.NoError(t, jerr)
+	assert.Equal(t, `[{"S":"STRING"},{"N":"123"},{"N":"-123"},{"N":"8"},{"N":"1.5"},{"BOOL":true},{"NULL":true},{"B":"QklOQVJZ"},{"SS":["STRING1","STRING2"]},{"NS":["1","2"]},{"S":"ATTR"}]`, string(j))
+
+	_, err = dynamodb.NewParameters(struct{}{})
+	assert.Error(t, err)
+}